@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+)
+
+type argocdGenerateCmdOptions struct {
+	sealedSecretsControllerNamespace string
+	privateKeyFiles                  []string
+}
+
+var argocdGenerateCmdOpts = &argocdGenerateCmdOptions{}
+
+func init() {
+	setSealedSecretsControllerNamespace(&argocdGenerateCmdOpts.sealedSecretsControllerNamespace)
+	addFlagPrivateKey(argocdGenerateCmd, &argocdGenerateCmdOpts.privateKeyFiles)
+}
+
+var argocdGenerateCmd = &cobra.Command{
+	Use:   "argocd-generate [PATH...]",
+	Short: "print manifests with plaintext digests on SealedSecrets, as an Argo CD plugin",
+	Long: `Print manifests with plaintext digests on SealedSecrets, as the generate command of an Argo CD
+config management plugin.
+
+Manifests in PATH, the current directory by default, are printed as-is except SealedSecrets,
+which are decrypted the same as show does, and annotated with HMAC-SHA256 digests of each value
+in ` + sealer.DigestAnnotation + `. The HMAC key is taken from $` + sealer.DigestKeyEnv + `
+or ` + sealer.DigestKeyFileName + ` in .sealer/ or user config dir. Hidden directories are skipped.
+
+Re-sealing changes every ciphertext while the digests stay the same, so with
+/spec/encryptedData in ignoreDifferences of the Application, Argo CD reports SealedSecrets
+out of sync only when their content actually changed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		digestKey := loadDigestKey(true, nil)
+
+		filenames, err := collectYAMLFiles(args)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		var output bytes.Buffer
+		for _, filename := range filenames {
+			if isInHiddenDir(filename) {
+				continue
+			}
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			for _, doc := range sealer.SplitYAMLDocuments(content) {
+				manifest := doc.Content
+				kind, err := sealer.DocumentKind(manifest)
+				if err != nil {
+					log.Fatalf("%s:%d: %v", filename, doc.Line, err)
+				}
+				if kind == "SealedSecret" {
+					secretYAML, err := sealer.Unseal(manifest, unsealOptions(argocdGenerateCmdOpts.sealedSecretsControllerNamespace, argocdGenerateCmdOpts.privateKeyFiles))
+					if err != nil {
+						log.Fatalf("%s:%d: %v", filename, doc.Line, err)
+					}
+					manifest = annotateDigests(digestKey, manifest, secretYAML)
+				}
+
+				output.WriteString("---\n")
+				output.Write(manifest)
+				if !bytes.HasSuffix(manifest, []byte("\n")) {
+					output.WriteString("\n")
+				}
+			}
+		}
+		os.Stdout.Write(output.Bytes())
+	},
+}
+
+// whether the file is in a directory like .git or .sealer, which doesn't contain manifests
+func isInHiddenDir(filename string) bool {
+	for _, part := range strings.Split(filepath.Dir(filepath.Clean(filename)), string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") && part != "." && part != ".." {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+)
+
+type certCmdOptions struct {
+	sealedSecretsControllerNamespace string
+	cert                             string
+	warnWithin                       time.Duration
+}
+
+var certCmdOpts = &certCmdOptions{}
+
+func init() {
+	setSealedSecretsControllerNamespace(&certCmdOpts.sealedSecretsControllerNamespace)
+
+	certCheckCmd.Flags().StringVar(&certCmdOpts.cert, "cert", "", "certificate file or http(s) URL to check, instead of fetching it from the controller")
+	certCheckCmd.Flags().DurationVar(&certCmdOpts.warnWithin, "warn-within", 30*24*time.Hour, "exit with non-zero status if the certificate expires within this duration")
+
+	certCmd.AddCommand(certCheckCmd)
+}
+
+var certCmd = &cobra.Command{
+	Use:   "cert",
+	Short: "inspect sealing certificates",
+	Long:  `Inspect sealing certificates.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var certCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "report the sealing certificate and check its expiry",
+	Long: `Report the sealing certificate and check its expiry.
+
+The certificate is fetched from the controller unless --cert is given. Exits with
+non-zero status if it's expired, not yet valid, or expires within --warn-within.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		certPEM, err := sealer.ReadCertificate(certCmdOpts.cert, sealer.Target{ControllerNamespace: certCmdOpts.sealedSecretsControllerNamespace})
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		info, err := sealer.ParseCertificateInfo(certPEM)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		now := time.Now()
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintf(w, "subject:\t%s\n", info.Subject)
+		fmt.Fprintf(w, "issuer:\t%s\n", info.Issuer)
+		fmt.Fprintf(w, "fingerprint (SHA256):\t%s\n", info.Fingerprint)
+		fmt.Fprintf(w, "key size:\t%d bits\n", info.KeySize)
+		fmt.Fprintf(w, "not before:\t%s\n", info.NotBefore.Format(time.RFC3339))
+		fmt.Fprintf(w, "not after:\t%s\n", info.NotAfter.Format(time.RFC3339))
+		fmt.Fprintf(w, "valid for:\t%s (%d days)\n", info.ValidFor(), int(info.ValidFor().Hours()/24))
+		fmt.Fprintf(w, "expires in:\t%s (%d days)\n", info.NotAfter.Sub(now).Round(time.Second), int(info.NotAfter.Sub(now).Hours()/24))
+		w.Flush()
+
+		switch {
+		case now.Before(info.NotBefore):
+			log.Printf("certificate is not valid until %s", info.NotBefore.Format(time.RFC3339))
+			os.Exit(exitCodeFailure)
+		case info.ExpiresWithin(0, now):
+			log.Printf("certificate has expired at %s", info.NotAfter.Format(time.RFC3339))
+			os.Exit(exitCodeFailure)
+		case info.ExpiresWithin(certCmdOpts.warnWithin, now):
+			log.Printf("certificate expires within %s, at %s", certCmdOpts.warnWithin, info.NotAfter.Format(time.RFC3339))
+			os.Exit(exitCodeFailure)
+		}
+	},
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
 	"github.com/shusugmt/kubectl-sealer/sealer"
@@ -16,8 +17,15 @@ import (
 type editCmdOptions struct {
 	filename                         string
 	sealedSecretsControllerNamespace string
+	privateKeyFiles                  []string
 	inPlace                          bool
 	forceUpdate                      bool
+	backupSuffix                     string
+	confirm                          bool
+	dryRun                           bool
+	noFullReseal                     bool
+	clusters                         []string
+	digests                          bool
 }
 
 var editCmdOpts = &editCmdOptions{}
@@ -25,64 +33,80 @@ var editCmdOpts = &editCmdOptions{}
 func init() {
 	addFlagFilename(editCmd, &editCmdOpts.filename, true)
 	setSealedSecretsControllerNamespace(&editCmdOpts.sealedSecretsControllerNamespace)
+	addFlagPrivateKey(editCmd, &editCmdOpts.privateKeyFiles)
 	editCmd.Flags().BoolVarP(&editCmdOpts.inPlace, "in-place", "i", false, "enable in-place edit; overwrite the input SealedSecret file with updated content")
+	addFlagBackup(editCmd, &editCmdOpts.backupSuffix)
 	editCmd.Flags().BoolVar(&editCmdOpts.forceUpdate, "force-update", false, "disable partial update mode; it will re-encrypt all values even if it's not modified")
+	editCmd.Flags().BoolVar(&editCmdOpts.noFullReseal, "no-full-reseal", false, "fail instead of re-encrypting all values when scope, name or namespace change requires it")
+	editCmd.Flags().BoolVar(&editCmdOpts.confirm, "confirm", false, "show a summary of changes and ask for confirmation before sealing")
+	editCmd.Flags().BoolVar(&editCmdOpts.dryRun, "dry-run", false, "show a summary of changes and print the resulting SealedSecret without writing the file")
+	addFlagFor(editCmd, &editCmdOpts.clusters)
+	addFlagDigests(editCmd, &editCmdOpts.digests)
 }
 
 var editCmd = &cobra.Command{
 	Use:   "edit",
 	Short: "edit SealedSecret in plain Secret format and re-encrypt afterwards",
-	Long:  `Edit SealedSecret in plain Secret format and re-encrypt afterwards.`,
+	Long: `Edit SealedSecret in plain Secret format and re-encrypt afterwards.
+
+With --for, -f is the copy of one of the clusters defined in clusters.yaml, and the edited Secret
+is sealed for all of them and written to their output paths, so that every copy has the same content.
+SealedSecrets of clusters without output path are printed instead:
+
+  kubectl sealer edit -f overlays/prod-eu/app.sealedsecret.yaml --for prod-eu,prod-us,staging`,
 	Run: func(cmd *cobra.Command, args []string) {
 
+		if editCmdOpts.forceUpdate && editCmdOpts.noFullReseal {
+			log.Fatalf("--force-update and --no-full-reseal are mutually exclusive")
+		}
+		if len(editCmdOpts.clusters) > 0 {
+			editForClusters()
+			return
+		}
+
 		srcSealedSecretYAML, err := os.ReadFile(editCmdOpts.filename)
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
 
-		srcSecretYAML, err := sealer.Unseal(srcSealedSecretYAML, editCmdOpts.sealedSecretsControllerNamespace)
+		digestKey := loadDigestKey(editCmdOpts.digests, srcSealedSecretYAML)
+
+		srcSecretYAML, err := sealer.Unseal(srcSealedSecretYAML, unsealOptions(editCmdOpts.sealedSecretsControllerNamespace, editCmdOpts.privateKeyFiles))
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
 
-		editedSecretYAML, err := sealer.EditSecretUntilOK(srcSecretYAML)
+		editedSecretYAML, err := sealer.EditSecretUntilOK(srcSecretYAML, rootCmdOpts.editor, loadPolicy())
 		if err != nil {
-			log.Fatalf("%v", err)
+			exitWithEditError(err)
 		}
 
+		if !editCmdOpts.forceUpdate && bytes.Equal(editedSecretYAML, srcSecretYAML) && !missingDigests(digestKey, srcSealedSecretYAML) {
+			// if it's same, do nothing
+			fmt.Println("no change")
+			os.Exit(0)
+		}
+
+		confirmChanges(srcSecretYAML, editedSecretYAML)
+
 		var updatedSealedSecretYAML []byte
 		if editCmdOpts.forceUpdate {
-			updatedSealedSecretYAML, err = sealer.Seal(editedSecretYAML, false)
+			updatedSealedSecretYAML, err = sealer.Seal(editedSecretYAML, false, sealer.Target{})
 			if err != nil {
 				log.Fatalf("%v", err)
 			}
 		} else {
-			// check whether the content is modified or not
-			if bytes.Equal(editedSecretYAML, srcSecretYAML) {
-				// if it's same, do nothing
-				fmt.Println("no change")
-				os.Exit(0)
-			}
-
-			updatedSealedSecretYAML, err = updateSealedSecret(srcSealedSecretYAML, srcSecretYAML, editedSecretYAML)
+			updatedSealedSecretYAML, err = updateSealedSecret(srcSealedSecretYAML, srcSecretYAML, editedSecretYAML, editCmdOpts.noFullReseal, sealer.Target{})
 			if err != nil {
 				log.Fatalf("%v", err)
 			}
 		}
+		updatedSealedSecretYAML = annotateDigests(digestKey, updatedSealedSecretYAML, editedSecretYAML)
 
-		if editCmdOpts.inPlace {
-			f, err := os.Create(editCmdOpts.filename)
-			if err != nil {
-				log.Fatalf("failed opening file to overwrite with updated SealedSecret: %s: %v", f.Name(), err)
-			}
-			_, err = f.Write(updatedSealedSecretYAML)
+		if editCmdOpts.inPlace && !editCmdOpts.dryRun {
+			err = sealer.WriteFileAtomic(editCmdOpts.filename, updatedSealedSecretYAML, 0644, editCmdOpts.backupSuffix)
 			if err != nil {
-				f.Close()
-				log.Fatalf("failed writing updated SealedSecret: %s: %v", f.Name(), err)
-			}
-			err = f.Close()
-			if err != nil {
-				log.Fatalf("failed writing updated SealedSecret: %s: %v", f.Name(), err)
+				log.Fatalf("failed writing updated SealedSecret: %v", err)
 			}
 		} else {
 			fmt.Print(string(updatedSealedSecretYAML))
@@ -90,10 +114,194 @@ var editCmd = &cobra.Command{
 	},
 }
 
+// show a summary of changes with --confirm or --dry-run, and ask for confirmation with --confirm
+func confirmChanges(srcSecretYAML []byte, editedSecretYAML []byte) {
+	if !editCmdOpts.confirm && !editCmdOpts.dryRun {
+		return
+	}
+
+	// summary goes to stderr so that stdout only contains the SealedSecret
+	summary, err := sealer.DescribeSecretChanges(srcSecretYAML, editedSecretYAML)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Fprint(os.Stderr, summary)
+
+	if editCmdOpts.confirm && !editCmdOpts.dryRun {
+		ok, err := sealer.Confirm("Seal and write these changes?")
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if !ok {
+			exitWithEditError(sealer.ErrEditCanceled)
+		}
+	}
+}
+
+// edit the copy given by -f, and seal the result for all clusters given by --for.
+// the other copies are updated partially if they have the same content as the edited one,
+// otherwise all of their values are re-encrypted, so that every copy ends up the same.
+func editForClusters() {
+	srcSealedSecretYAML, err := os.ReadFile(editCmdOpts.filename)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	var srcSealedSecret ssv1alpha1.SealedSecret
+	err = yaml.Unmarshal(srcSealedSecretYAML, &srcSealedSecret)
+	if err != nil {
+		log.Fatalf("error unmarshalling yaml to SealedSecret: %v", err)
+	}
+
+	clusters := loadClusters(editCmdOpts.clusters, editCmdOpts.sealedSecretsControllerNamespace)
+	var srcCluster *sealer.Cluster
+	for _, cluster := range clusters {
+		if cluster.Output == "" {
+			continue
+		}
+		path, err := cluster.OutputPath(srcSealedSecret.Name, srcSealedSecret.Namespace)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if isSameFile(path, editCmdOpts.filename) {
+			srcCluster = cluster
+			break
+		}
+	}
+	if srcCluster == nil {
+		log.Fatalf("%s is not the output of any of the clusters %s", editCmdOpts.filename, strings.Join(editCmdOpts.clusters, ", "))
+	}
+
+	digestKey := loadDigestKey(editCmdOpts.digests, srcSealedSecretYAML)
+
+	srcSecretYAML, err := sealer.Unseal(srcSealedSecretYAML, clusterUnsealOptions(srcCluster, editCmdOpts.privateKeyFiles))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	editedSecretYAML, err := sealer.EditSecretUntilOK(srcSecretYAML, rootCmdOpts.editor, loadPolicy())
+	if err != nil {
+		exitWithEditError(err)
+	}
+	if !editCmdOpts.forceUpdate && bytes.Equal(editedSecretYAML, srcSecretYAML) && !missingDigests(digestKey, srcSealedSecretYAML) {
+		fmt.Println("no change")
+		os.Exit(0)
+	}
+	confirmChanges(srcSecretYAML, editedSecretYAML)
+
+	var editedSecret corev1.Secret
+	err = yaml.Unmarshal(editedSecretYAML, &editedSecret)
+	if err != nil {
+		log.Fatalf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+
+	results := []clusterSealedSecret{}
+	for _, cluster := range clusters {
+		if cluster.Output == "" {
+			// there's no copy to update, so seal it from scratch and print it, the same as seal --for does
+			log.Printf("%s: no output path, printing the SealedSecret instead", cluster.Alias)
+			sealedSecretYAML, err := sealer.Seal(editedSecretYAML, false, cluster.Target())
+			if err != nil {
+				log.Fatalf("cluster %s: %v", cluster.Alias, err)
+			}
+			sealedSecretYAML = annotateDigests(digestKey, sealedSecretYAML, editedSecretYAML)
+			results = append(results, clusterSealedSecret{cluster: cluster, sealedSecretYAML: sealedSecretYAML})
+			continue
+		}
+
+		srcPath, err := cluster.OutputPath(srcSealedSecret.Name, srcSealedSecret.Namespace)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		path, err := cluster.OutputPath(editedSecret.Name, editedSecret.Namespace)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		var updatedSealedSecretYAML []byte
+		if cluster == srcCluster {
+			updatedSealedSecretYAML, err = updateClusterCopy(cluster, srcSealedSecretYAML, srcSecretYAML, editedSecretYAML)
+		} else {
+			updatedSealedSecretYAML, err = updateOtherClusterCopy(cluster, srcPath, srcSecretYAML, editedSecretYAML)
+		}
+		if err != nil {
+			log.Fatalf("cluster %s: %v", cluster.Alias, err)
+		}
+		if path != srcPath {
+			log.Printf("warning: %s: writing to %s, remove %s if it's no longer needed", cluster.Alias, path, srcPath)
+		}
+
+		updatedSealedSecretYAML = annotateDigests(digestKey, updatedSealedSecretYAML, editedSecretYAML)
+		results = append(results, clusterSealedSecret{cluster: cluster, path: path, sealedSecretYAML: updatedSealedSecretYAML})
+	}
+
+	if editCmdOpts.dryRun {
+		for i := range results {
+			results[i].path = ""
+		}
+	}
+	writeClusterSealedSecrets(results, editCmdOpts.backupSuffix)
+}
+
+func updateClusterCopy(cluster *sealer.Cluster, sealedSecretYAML []byte, secretYAML []byte, editedSecretYAML []byte) ([]byte, error) {
+	if editCmdOpts.forceUpdate {
+		return sealer.Seal(editedSecretYAML, false, cluster.Target())
+	}
+	return updateSealedSecret(sealedSecretYAML, secretYAML, editedSecretYAML, editCmdOpts.noFullReseal, cluster.Target())
+}
+
+// update the copy of a cluster other than the edited one. it's updated partially only if
+// it's known to have the same content as the edited copy; keys of the edited copy may not
+// be able to decrypt it, so it's decrypted with the keys of its own cluster, unless local private keys are given.
+func updateOtherClusterCopy(cluster *sealer.Cluster, path string, srcSecretYAML []byte, editedSecretYAML []byte) ([]byte, error) {
+	reseal := func(reason string) ([]byte, error) {
+		if editCmdOpts.noFullReseal {
+			return nil, fmt.Errorf("refusing to re-encrypt all values since %s", reason)
+		}
+		log.Printf("warning: %s: re-encrypting all values since %s", cluster.Alias, reason)
+		return sealer.Seal(editedSecretYAML, false, cluster.Target())
+	}
+
+	sealedSecretYAML, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return sealer.Seal(editedSecretYAML, false, cluster.Target())
+	}
+	if err != nil {
+		return nil, err
+	}
+	secretYAML, err := sealer.Unseal(sealedSecretYAML, clusterUnsealOptions(cluster, editCmdOpts.privateKeyFiles))
+	if err != nil {
+		return reseal(fmt.Sprintf("%s can't be decrypted: %v", path, err))
+	}
+	if !bytes.Equal(secretYAML, srcSecretYAML) {
+		return reseal(fmt.Sprintf("%s differs from %s", path, editCmdOpts.filename))
+	}
+	return updateClusterCopy(cluster, sealedSecretYAML, secretYAML, editedSecretYAML)
+}
+
+// where Unseal gets the sealing keys of the cluster from; the controller through the context of the cluster,
+// or local private keys if given
+func clusterUnsealOptions(cluster *sealer.Cluster, privateKeyFiles []string) sealer.UnsealOptions {
+	opts := unsealOptions(cluster.ControllerNamespace, privateKeyFiles)
+	opts.Target = cluster.Target()
+	return opts
+}
+
+func isSameFile(a string, b string) bool {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(aInfo, bInfo)
+}
+
 /*
   update SealedSecret with partial update support
 */
-func updateSealedSecret(sealedSecretYAML []byte, secretYAML []byte, editedSecretYAML []byte) (updatedSealedSecretYAML []byte, err error) {
+func updateSealedSecret(sealedSecretYAML []byte, secretYAML []byte, editedSecretYAML []byte, noFullReseal bool, target sealer.Target) (updatedSealedSecretYAML []byte, err error) {
 
 	// build struct from yaml
 	var sealedSecret ssv1alpha1.SealedSecret
@@ -115,36 +323,19 @@ func updateSealedSecret(sealedSecretYAML []byte, secretYAML []byte, editedSecret
 	}
 
 	// ---- ---- ---- ---- ----
-	// step.1 check scope change
+	// step.1 check scope and name/namespace change
 
-	// if scope chas been hanged
-	if ssv1alpha1.SecretScope(&editedSecret) != ssv1alpha1.SecretScope(&secret) {
-		// then need to re-seal entire Secret
-		return sealer.Seal(editedSecretYAML, false)
-	}
-
-	// ---- ---- ---- ---- ----
-	// step.2 check name/namespace change
-
-	// if scope is strict
-	if ssv1alpha1.SecretScope(&editedSecret) == ssv1alpha1.StrictScope {
-		// and either namespace or name has been changed
-		if secret.Namespace != editedSecret.Namespace || secret.Name != editedSecret.Name {
-			// then need to re-seal entire Secret
-			return sealer.Seal(editedSecretYAML, false)
-		}
-	}
-	// if scope is namespace-wide
-	if ssv1alpha1.SecretScope(&editedSecret) == ssv1alpha1.NamespaceWideScope {
-		// and namespace has been changed
-		if secret.Namespace != editedSecret.Namespace {
-			// then need to re-seal entire Secret
-			return sealer.Seal(editedSecretYAML, false)
+	if reason := sealer.FullResealReason(&secret, &editedSecret); reason != "" {
+		if noFullReseal {
+			return nil, fmt.Errorf("refusing to re-encrypt all values since %s", reason)
 		}
+		// re-sealing entire Secret produces a diff on every value, so let the user know why
+		log.Printf("warning: re-encrypting all values since %s", reason)
+		return sealer.Seal(editedSecretYAML, false, target)
 	}
 
 	// ---- ---- ---- ---- ----
-	// step.3 ensure metadata update
+	// step.2 ensure metadata update
 
 	// create a copy of edited Secret for generating a skeleton SealdSecret, which contains
 	// all data(e.g. metadata.name, ns, labels, annotations, etc) inheriting source Secret
@@ -160,7 +351,7 @@ func updateSealedSecret(sealedSecretYAML []byte, secretYAML []byte, editedSecret
 	}
 	// generate skeleton SealedSecret from edited Secret
 	// ensuring all metadata is updated
-	newSealedSecretYAML, err := sealer.Seal(editedSecretCopyYAML, true)
+	newSealedSecretYAML, err := sealer.Seal(editedSecretCopyYAML, true, target)
 	if err != nil {
 		return nil, err
 	}
@@ -175,35 +366,37 @@ func updateSealedSecret(sealedSecretYAML []byte, secretYAML []byte, editedSecret
 	newSealedSecret.Spec.EncryptedData = sealedSecret.Spec.EncryptedData
 
 	// ---- ---- ---- ---- ----
-	// step.4 fill spec.encryptedData keeping unchanged kv pairs left as-is
+	// step.3 fill spec.encryptedData keeping unchanged kv pairs left as-is
 
-	// step.4-1
+	// step.3-1
 	// add kv pairs those are entirely new
 	addedKeys := sealer.GetKeyDiff(editedSecret.StringData, secret.StringData)
 	for _, addedKey := range addedKeys {
 		// get raw encrypted value
 		value := []byte(editedSecret.StringData[addedKey])
-		encryptedValue, err := sealer.EncryptRaw(value, editedSecret)
+		encryptedValue, err := sealer.EncryptRaw(value, editedSecret, target)
 		if err != nil {
 			return nil, err
 		}
 		newSealedSecret.Spec.EncryptedData[addedKey] = string(encryptedValue)
 	}
 
-	// step.4-2
+	// step.3-2
 	// update kv pairs those values are changed
+	// iterate in sorted order, so that kubeseal is invoked in the same order every time.
+	// derived keys show up here only when their inputs changed, since they are recomputed the same way otherwise.
 	updatedKeyVals := sealer.GetUpdatedExisting(editedSecret.StringData, secret.StringData)
-	for k, v := range updatedKeyVals {
+	for _, k := range sealer.SortedKeys(updatedKeyVals) {
 		// get raw encrypted value
-		value := []byte(v)
-		encryptedValue, err := sealer.EncryptRaw(value, editedSecret)
+		value := []byte(updatedKeyVals[k])
+		encryptedValue, err := sealer.EncryptRaw(value, editedSecret, target)
 		if err != nil {
 			return nil, err
 		}
 		newSealedSecret.Spec.EncryptedData[k] = string(encryptedValue)
 	}
 
-	// step.4-3
+	// step.3-3
 	// delete kv pairs those are removed
 	deletedKeys := sealer.GetKeyDiff(secret.StringData, editedSecret.StringData)
 	for _, deletedKey := range deletedKeys {
@@ -215,5 +408,5 @@ func updateSealedSecret(sealedSecretYAML []byte, secretYAML []byte, editedSecret
 	if err != nil {
 		return nil, err
 	}
-	return updatedSealedSecretYAML, nil
+	return sealer.CanonicalizeSealedSecretYAML(updatedSealedSecretYAML)
 }
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+)
+
+// kubectl and kubeseal stubs, which record their arguments to $STUB_LOG
+const (
+	stubKubectl = `#!/bin/sh
+echo "kubectl $*" >> "$STUB_LOG"
+printf 'apiVersion: v1\nkind: List\nitems: []\n'
+`
+	stubKubeseal = `#!/bin/sh
+echo "kubeseal $*" >> "$STUB_LOG"
+cat > /dev/null
+case "$*" in
+*--recovery-unseal*)
+  echo '{"apiVersion":"v1","kind":"Secret","metadata":{"name":"foo","namespace":"app"},"data":{"password":"b2xk"}}';;
+*--raw*)
+  printf 'AgBnZXc=';;
+*)
+  printf 'apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: foo\n  namespace: app\nspec:\n  template:\n    metadata:\n      name: foo\n      namespace: app\n';;
+esac
+`
+	testClusterSealedSecret = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: foo
+  namespace: app
+spec:
+  encryptedData:
+    password: AgBvbGQ=
+  template:
+    metadata:
+      name: foo
+      namespace: app
+`
+)
+
+// put kubectl and kubeseal stubs first in PATH, and returns the file they record their arguments to
+func installStubs(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("stubs are shell scripts")
+	}
+	dir := t.TempDir()
+	for name, script := range map[string]string{"kubectl": stubKubectl, "kubeseal": stubKubeseal} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stubLog := filepath.Join(dir, "stub.log")
+
+	for key, value := range map[string]string{"PATH": dir + string(os.PathListSeparator) + os.Getenv("PATH"), "STUB_LOG": stubLog} {
+		// the loop variable is shared among iterations, but each cleanup must restore its own
+		key := key
+		original, exists := os.LookupEnv(key)
+		os.Setenv(key, value)
+		t.Cleanup(func() {
+			if exists {
+				os.Setenv(key, original)
+			} else {
+				os.Unsetenv(key)
+			}
+		})
+	}
+	return stubLog
+}
+
+func TestUpdateOtherClusterCopy(t *testing.T) {
+	stubLog := installStubs(t)
+	editCmdOpts.noFullReseal = true
+	defer func() { editCmdOpts.noFullReseal = false }()
+
+	dir := t.TempDir()
+	clusters := []*sealer.Cluster{
+		{Alias: "prod-eu", Context: "ctx-eu", ControllerNamespace: "kube-system"},
+		{Alias: "prod-us", Context: "ctx-us", ControllerNamespace: "sealed-secrets"},
+	}
+	paths := map[string]string{}
+	for _, cluster := range clusters {
+		paths[cluster.Alias] = filepath.Join(dir, cluster.Alias+".yaml")
+		if err := os.WriteFile(paths[cluster.Alias], []byte(testClusterSealedSecret), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srcSecretYAML, err := sealer.Unseal([]byte(testClusterSealedSecret), clusterUnsealOptions(clusters[0], nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	editedSecretYAML := []byte(strings.Replace(string(srcSecretYAML), "password: old", "password: new", 1))
+
+	for _, cluster := range clusters {
+		os.Remove(stubLog)
+		// with --no-full-reseal, failing to decrypt the copy is an error instead of falling back to full reseal
+		updated, err := updateOtherClusterCopy(cluster, paths[cluster.Alias], srcSecretYAML, editedSecretYAML)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", cluster.Alias, err)
+			continue
+		}
+		if !strings.Contains(string(updated), "password: AgBnZXc=") {
+			t.Errorf("%v: expected password to be re-encrypted, got:\n%s", cluster.Alias, updated)
+		}
+
+		calls, err := os.ReadFile(stubLog)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(string(calls)), "\n") {
+			if strings.HasPrefix(line, "kubeseal --recovery-unseal") {
+				continue
+			}
+			if !strings.Contains(line, "--context "+cluster.Context) {
+				t.Errorf("%v: expected --context %s, got: %s", cluster.Alias, cluster.Context, line)
+			}
+			if !strings.Contains(line, cluster.ControllerNamespace) {
+				t.Errorf("%v: expected controller namespace %s, got: %s", cluster.Alias, cluster.ControllerNamespace, line)
+			}
+		}
+	}
+}
+
+func TestConfirmChanges(t *testing.T) {
+	// confirmChanges exits when canceled, so it's run in a child process of the test binary
+	if mode := os.Getenv("TEST_CONFIRM_CHANGES"); mode != "" {
+		editCmdOpts.confirm = mode == "confirm"
+		editCmdOpts.dryRun = mode == "dry-run"
+		confirmChanges([]byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\nstringData:\n  password: old\n"),
+			[]byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\nstringData:\n  password: new\n"))
+		return
+	}
+
+	tests := map[string]struct {
+		mode             string
+		stdin            string
+		expectedExitCode int
+		expectSummary    bool
+		expectPrompt     bool
+	}{
+		"neither":            {mode: "none"},
+		"dry run":            {mode: "dry-run", stdin: "n\n", expectSummary: true},
+		"confirm, yes":       {mode: "confirm", stdin: "y\n", expectSummary: true, expectPrompt: true},
+		"confirm, no":        {mode: "confirm", stdin: "n\n", expectedExitCode: exitCodeCanceled, expectSummary: true, expectPrompt: true},
+		"confirm, no answer": {mode: "confirm", stdin: "", expectedExitCode: exitCodeCanceled, expectSummary: true, expectPrompt: true},
+	}
+
+	for name, test := range tests {
+		command := exec.Command(os.Args[0], "-test.run=^TestConfirmChanges$")
+		command.Env = append(os.Environ(), "TEST_CONFIRM_CHANGES="+test.mode)
+		command.Stdin = strings.NewReader(test.stdin)
+		var stderr bytes.Buffer
+		command.Stderr = &stderr
+		err := command.Run()
+
+		exitCode := 0
+		var exitError *exec.ExitError
+		if errors.As(err, &exitError) {
+			exitCode = exitError.ExitCode()
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if exitCode != test.expectedExitCode {
+			t.Errorf("%v: expected exit code %d, got %d: %s", name, test.expectedExitCode, exitCode, stderr.String())
+		}
+		if strings.Contains(stderr.String(), "~ stringData[password] (changed)") != test.expectSummary {
+			t.Errorf("%v: expected summary %v, got: %s", name, test.expectSummary, stderr.String())
+		}
+		if strings.Contains(stderr.String(), "Seal and write these changes?") != test.expectPrompt {
+			t.Errorf("%v: expected prompt %v, got: %s", name, test.expectPrompt, stderr.String())
+		}
+	}
+}
@@ -5,18 +5,27 @@ import (
 	"encoding/pem"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+	"github.com/shusugmt/kubectl-sealer/sealer"
 	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	certutil "k8s.io/client-go/util/cert"
 	"k8s.io/client-go/util/keyutil"
 )
 
 type genkeyCmdOptions struct {
-	keySize  int
-	validFor time.Duration
-	myCN     string
+	keySize                          int
+	validFor                         time.Duration
+	myCN                             string
+	outputDir                        string
+	asSecret                         bool
+	certOnly                         bool
+	encryptKey                       bool
+	sealedSecretsControllerNamespace string
 }
 
 var genkeyCmdOpts = &genkeyCmdOptions{}
@@ -26,6 +35,11 @@ func init() {
 	genkeyCmd.Flags().IntVar(&genkeyCmdOpts.keySize, "key-size", 4096, "size of encryption key")
 	genkeyCmd.Flags().DurationVar(&genkeyCmdOpts.validFor, "key-ttl", 10*365*24*time.Hour, "duration that certificate is valid for")
 	genkeyCmd.Flags().StringVar(&genkeyCmdOpts.myCN, "my-cn", "", "common name to be used as issuer/subject DN in generated certificate (default \"\")")
+	genkeyCmd.Flags().StringVar(&genkeyCmdOpts.outputDir, "output-dir", "", "write tls.crt and tls.key into this directory instead of printing them")
+	genkeyCmd.Flags().BoolVar(&genkeyCmdOpts.asSecret, "as-secret", false, "print a Secret for the controller namespace, which the controller picks up as a sealing key")
+	genkeyCmd.Flags().BoolVar(&genkeyCmdOpts.certOnly, "cert-only", false, "print only the certificate, which can be committed for offline sealing; requires --output-dir to keep the key")
+	genkeyCmd.Flags().BoolVar(&genkeyCmdOpts.encryptKey, "encrypt-key", false, "encrypt the private key with a passphrase, taken from $"+sealer.PassphraseEnv+", --passphrase-fd or prompted")
+	setSealedSecretsControllerNamespace(&genkeyCmdOpts.sealedSecretsControllerNamespace)
 }
 
 var genkeyCmd = &cobra.Command{
@@ -34,6 +48,16 @@ var genkeyCmd = &cobra.Command{
 	Long:  `Generate a new sealing key pair.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
+		if genkeyCmdOpts.asSecret && genkeyCmdOpts.certOnly {
+			log.Fatalf("--as-secret and --cert-only are mutually exclusive")
+		}
+		if genkeyCmdOpts.asSecret && genkeyCmdOpts.encryptKey {
+			log.Fatalf("--as-secret and --encrypt-key are mutually exclusive, since the controller can't read encrypted keys")
+		}
+		if genkeyCmdOpts.certOnly && genkeyCmdOpts.outputDir == "" {
+			log.Fatalf("--cert-only requires --output-dir, otherwise the private key would be lost")
+		}
+
 		key, cert, err := crypto.GeneratePrivateKeyAndCert(genkeyCmdOpts.keySize, genkeyCmdOpts.validFor, genkeyCmdOpts.myCN)
 		if err != nil {
 			log.Fatalf("%v", err)
@@ -41,6 +65,67 @@ var genkeyCmd = &cobra.Command{
 
 		certPEM := pem.EncodeToMemory(&pem.Block{Type: certutil.CertificateBlockType, Bytes: cert.Raw})
 		keyPEM := pem.EncodeToMemory(&pem.Block{Type: keyutil.RSAPrivateKeyBlockType, Bytes: x509.MarshalPKCS1PrivateKey(key)})
-		fmt.Printf("%s%s", certPEM, keyPEM)
+
+		if genkeyCmdOpts.encryptKey {
+			passphrase, err := sealer.ReadPassphrase(rootCmdOpts.passphraseFD, true)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			keyPEM, err = sealer.EncryptWithPassphrase(keyPEM, passphrase)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
+		if genkeyCmdOpts.outputDir != "" {
+			err = writeKeyPair(genkeyCmdOpts.outputDir, certPEM, keyPEM)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
+		switch {
+		case genkeyCmdOpts.asSecret:
+			secretYAML, err := sealer.SealingKeySecretYAML(genkeyCmdOpts.sealedSecretsControllerNamespace, certPEM, keyPEM)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			fmt.Print(string(secretYAML))
+		case genkeyCmdOpts.certOnly:
+			fmt.Print(string(certPEM))
+		case genkeyCmdOpts.outputDir == "":
+			fmt.Printf("%s%s", certPEM, keyPEM)
+		}
 	},
 }
+
+// write tls.crt and tls.key into dir. existing files are never overwritten, since
+// losing a sealing key means losing every secret sealed with it.
+func writeKeyPair(dir string, certPEM []byte, keyPEM []byte) error {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return fmt.Errorf("error creating output directory: %v", err)
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{corev1.TLSCertKey, certPEM},
+		{corev1.TLSPrivateKeyKey, keyPEM},
+	}
+	for _, f := range files {
+		filename := filepath.Join(dir, f.name)
+		if _, err := os.Lstat(filename); err == nil {
+			return fmt.Errorf("refusing to overwrite existing file: %s", filename)
+		}
+	}
+	for _, f := range files {
+		filename := filepath.Join(dir, f.name)
+		err = sealer.WriteFileAtomic(filename, f.content, 0600, "")
+		if err != nil {
+			return fmt.Errorf("failed writing %s: %v", filename, err)
+		}
+	}
+	return nil
+}
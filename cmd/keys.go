@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+)
+
+type keysCmdOptions struct {
+	sealedSecretsControllerNamespace string
+	// fetch-cert
+	certOutput string
+	// backup
+	backupOutput string
+	// import
+	certFile string
+	keyFile  string
+}
+
+var keysCmdOpts = &keysCmdOptions{}
+
+func init() {
+	setSealedSecretsControllerNamespace(&keysCmdOpts.sealedSecretsControllerNamespace)
+
+	keysFetchCertCmd.Flags().StringVarP(&keysCmdOpts.certOutput, "output", "o", "", "write the certificate to this file instead of stdout")
+	keysBackupCmd.Flags().StringVarP(&keysCmdOpts.backupOutput, "output", "o", "", "file to write the encrypted backup to")
+	keysBackupCmd.MarkFlagRequired("output")
+	keysImportCmd.Flags().StringVar(&keysCmdOpts.certFile, "cert-file", "", "PEM encoded certificate, e.g. tls.crt written by genkey --output-dir")
+	keysImportCmd.Flags().StringVar(&keysCmdOpts.keyFile, "key-file", "", "PEM encoded private key, e.g. tls.key written by genkey --output-dir, possibly passphrase encrypted")
+	keysImportCmd.MarkFlagRequired("cert-file")
+	keysImportCmd.MarkFlagRequired("key-file")
+
+	keysCmd.AddCommand(keysListCmd)
+	keysCmd.AddCommand(keysFetchCertCmd)
+	keysCmd.AddCommand(keysBackupCmd)
+	keysCmd.AddCommand(keysImportCmd)
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "manage sealing keys of the controller",
+	Long: `Manage sealing keys of the controller.
+
+The controller namespace is taken from $SEALED_SECRETS_CONTROLLER_NAMESPACE, or kube-system.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list sealing keys with their fingerprint, creation time, expiry and status",
+	Long: `List sealing keys with their fingerprint, creation time, expiry and status.
+
+Keys are ordered by creation time. The controller seals with the latest one.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		sealingKeysYAML, err := sealer.GetSealingKeysYAML(sealer.Target{ControllerNamespace: keysCmdOpts.sealedSecretsControllerNamespace})
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		keys, err := sealer.ParseSealingKeys(sealingKeysYAML)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tFINGERPRINT\tCREATED\tEXPIRES\tSTATUS")
+		for _, key := range keys {
+			expires := key.Certificate.NotAfter.Format(time.RFC3339)
+			if key.Certificate.ExpiresWithin(0, time.Now()) {
+				expires += " (expired)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", key.Name, key.Certificate.Fingerprint, key.CreationTimestamp.Format(time.RFC3339), expires, key.Status)
+		}
+		w.Flush()
+	},
+}
+
+var keysFetchCertCmd = &cobra.Command{
+	Use:   "fetch-cert",
+	Short: "fetch the certificate the controller currently seals with",
+	Long:  `Fetch the certificate the controller currently seals with, so that it can be committed for offline sealing.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		certPEM, err := sealer.FetchCert(sealer.Target{ControllerNamespace: keysCmdOpts.sealedSecretsControllerNamespace})
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		info, err := sealer.ParseCertificateInfo(certPEM)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		if keysCmdOpts.certOutput != "" {
+			err = sealer.WriteFileAtomic(keysCmdOpts.certOutput, certPEM, 0644, "")
+			if err != nil {
+				log.Fatalf("failed writing certificate: %v", err)
+			}
+			log.Printf("saved certificate %s to %s", info, keysCmdOpts.certOutput)
+		} else {
+			fmt.Print(string(certPEM))
+		}
+	},
+}
+
+var keysBackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "export all sealing keys including private keys into a passphrase encrypted file",
+	Long: `Export all sealing keys including private keys into a passphrase encrypted file.
+
+The passphrase is taken from $SEALER_KEY_PASSPHRASE, --passphrase-fd, or prompted twice.
+The backup can be given to show, edit and set as --private-key. Without the backup,
+SealedSecrets can't be decrypted once the controller namespace is lost.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		sealingKeysYAML, err := sealer.GetSealingKeysYAML(sealer.Target{ControllerNamespace: keysCmdOpts.sealedSecretsControllerNamespace})
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		keys, err := sealer.ParseSealingKeys(sealingKeysYAML)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if len(keys) == 0 {
+			log.Fatalf("no sealing keys found in namespace %s", keysCmdOpts.sealedSecretsControllerNamespace)
+		}
+
+		passphrase, err := sealer.ReadPassphrase(rootCmdOpts.passphraseFD, true)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		encrypted, err := sealer.EncryptWithPassphrase(sealingKeysYAML, passphrase)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		err = sealer.WriteFileAtomic(keysCmdOpts.backupOutput, encrypted, 0600, "")
+		if err != nil {
+			log.Fatalf("failed writing backup: %v", err)
+		}
+		log.Printf("saved %d sealing keys to %s", len(keys), keysCmdOpts.backupOutput)
+	},
+}
+
+var keysImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "install a key pair, e.g. generated by genkey, as a new active sealing key",
+	Long: `Install a key pair, e.g. generated by genkey, as a new active sealing key.
+
+The controller picks up new keys on start, so restart it afterwards.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		certPEM, err := os.ReadFile(keysCmdOpts.certFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		keyPEM, err := os.ReadFile(keysCmdOpts.keyFile)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if sealer.IsPassphraseEncrypted(keyPEM) {
+			passphrase, err := sealer.ReadPassphrase(rootCmdOpts.passphraseFD, false)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			keyPEM, err = sealer.DecryptWithPassphrase(keyPEM, passphrase)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
+		name, err := sealer.ImportSealingKey(keysCmdOpts.sealedSecretsControllerNamespace, certPEM, keyPEM)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		log.Printf("created %s; restart the controller to start sealing with it, e.g. kubectl -n %s rollout restart deployment sealed-secrets-controller", name, keysCmdOpts.sealedSecretsControllerNamespace)
+	},
+}
@@ -0,0 +1,17 @@
+package cmd
+
+import "testing"
+
+func TestKeysOutputFlags(t *testing.T) {
+	defer func() { keysCmdOpts.certOutput, keysCmdOpts.backupOutput = "", "" }()
+
+	if err := keysFetchCertCmd.Flags().Set("output", "cert.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if err := keysBackupCmd.Flags().Set("output", "keys.backup"); err != nil {
+		t.Fatal(err)
+	}
+	if keysCmdOpts.certOutput != "cert.pem" || keysCmdOpts.backupOutput != "keys.backup" {
+		t.Errorf("expected -o of fetch-cert and backup to be independent, got %q and %q", keysCmdOpts.certOutput, keysCmdOpts.backupOutput)
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"log"
+	"os"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var krmCmd = &cobra.Command{
+	Use:   "krm",
+	Short: "run as a KRM function, e.g. from kustomize, to seal or reject plain Secrets",
+	Long: `Run as a KRM function, e.g. from kustomize, to seal or reject plain Secrets.
+
+Reads a ResourceList from stdin and writes the result to stdout. What it does depends on
+the kind of the function config:
+
+  ` + sealer.KindSealedSecretGenerator + `  seal plain Secrets in the items, and Secrets generated like
+                         secretGenerator, with the certificate given as cert. Secrets
+                         without namespace are put in namespace of the function config
+  ` + sealer.KindSealedSecretValidator + `  fail if any plain Secret is left in the items
+
+The apiVersion of the function config is ` + sealer.KRMAPIVersion + `. Since kustomize runs
+exec functions without arguments, point it at a script running "kubectl-sealer krm".`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		list, err := sealer.ReadResourceList(os.Stdin)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		failed := false
+		switch list.FunctionKind() {
+		case sealer.KindSealedSecretGenerator:
+			err = sealResourceList(list)
+			if err != nil {
+				list.Results = append(list.Results, sealer.KRMResult{Message: err.Error(), Severity: sealer.LintSeverityError})
+				failed = true
+			}
+		case sealer.KindSealedSecretValidator:
+			failed = list.ValidateNoPlainSecrets() > 0
+		default:
+			log.Fatalf("unknown function config kind: %q, must be %s or %s", list.FunctionKind(), sealer.KindSealedSecretGenerator, sealer.KindSealedSecretValidator)
+		}
+
+		if list.Items == nil {
+			list.Items = []map[string]interface{}{}
+		}
+		resourceListYAML, err := yaml.Marshal(list)
+		if err != nil {
+			log.Fatalf("error marshalling ResourceList: %v", err)
+		}
+		os.Stdout.Write(resourceListYAML)
+		if failed {
+			os.Exit(exitCodeFailure)
+		}
+	},
+}
+
+// seal Secrets offline with the certificate of the function config, enforcing the policy the same as edit does
+func sealResourceList(list *sealer.ResourceList) error {
+	generator, err := list.SealedSecretGenerator()
+	if err != nil {
+		return err
+	}
+	policy := loadPolicy()
+	return list.SealSecrets(generator, func(secretYAML []byte) ([]byte, error) {
+		secretYAML, err := sealer.ApplyDerivations(secretYAML)
+		if err != nil {
+			return nil, err
+		}
+		err = sealer.CheckSecretYAML(secretYAML, policy)
+		if err != nil {
+			return nil, err
+		}
+		return sealer.Seal(secretYAML, false, sealer.Target{Cert: generator.Cert})
+	})
+}
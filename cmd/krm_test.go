@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+)
+
+// base64 encoded certificate and key, as they appear in data of a kubernetes.io/tls Secret
+func testTLSData(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return base64.StdEncoding.EncodeToString(certPEM), base64.StdEncoding.EncodeToString(keyPEM)
+}
+
+// use the policy for the test, as if it's given by --policy
+func usePolicy(t *testing.T, policyYAML string) {
+	filename := filepath.Join(t.TempDir(), sealer.PolicyFileName)
+	if err := os.WriteFile(filename, []byte(policyYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	original := rootCmdOpts.policy
+	rootCmdOpts.policy = filename
+	t.Cleanup(func() { rootCmdOpts.policy = original })
+}
+
+func TestSealResourceList(t *testing.T) {
+	installStubs(t)
+	usePolicy(t, "rules:\n- name: no passwords\n  forbiddenKeys: [\"*password*\"]\n")
+	tlsCrt, tlsKey := testTLSData(t)
+
+	tests := map[string]struct {
+		item          map[string]interface{}
+		expectedError string
+	}{
+		"tls in data, as kustomize secretGenerator makes": {
+			item: map[string]interface{}{
+				"apiVersion": "v1", "kind": "Secret", "type": "kubernetes.io/tls",
+				"metadata": map[string]interface{}{"name": "foo", "namespace": "app"},
+				"data":     map[string]interface{}{"tls.crt": tlsCrt, "tls.key": tlsKey},
+			},
+		},
+		"forbidden key in data": {
+			item: map[string]interface{}{
+				"apiVersion": "v1", "kind": "Secret",
+				"metadata": map[string]interface{}{"name": "foo", "namespace": "app"},
+				"data":     map[string]interface{}{"db-password": base64.StdEncoding.EncodeToString([]byte("hunter2"))},
+			},
+			expectedError: "data[db-password]: Forbidden",
+		},
+	}
+
+	for name, test := range tests {
+		list := &sealer.ResourceList{
+			APIVersion:     "config.kubernetes.io/v1",
+			Kind:           "ResourceList",
+			Items:          []map[string]interface{}{test.item},
+			FunctionConfig: map[string]interface{}{"apiVersion": sealer.KRMAPIVersion, "kind": sealer.KindSealedSecretGenerator, "cert": "cert.pem"},
+		}
+		err := sealResourceList(list)
+		if test.expectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("%v: expected error %q, got: %v", name, test.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if kind := list.Items[0]["kind"]; kind != "SealedSecret" {
+			t.Errorf("%v: expected SealedSecret, got %v", name, kind)
+		}
+	}
+}
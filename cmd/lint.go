@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+)
+
+type lintCmdOptions struct {
+	output string
+}
+
+var lintCmdOpts = &lintCmdOptions{}
+
+func init() {
+	lintCmd.Flags().StringVarP(&lintCmdOpts.output, "output", "o", "text", "output format; one of text, json or sarif")
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint PATH...",
+	Short: "check SealedSecret manifests structurally without decrypting them",
+	Long: `Check SealedSecret manifests structurally without decrypting them.
+
+Directories are searched recursively for *.yaml and *.yml files. Documents other than
+SealedSecret are ignored. Documents that can't be parsed at all are reported as warnings,
+since they may not be SealedSecrets. Exits with non-zero status if any error is found.`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var format func([]lintResult) ([]byte, error)
+		switch lintCmdOpts.output {
+		case "text":
+			format = formatLintResultsText
+		case "json":
+			format = formatLintResultsJSON
+		case "sarif":
+			format = formatLintResultsSARIF
+		default:
+			log.Fatalf("unknown output format: %q", lintCmdOpts.output)
+		}
+
+		filenames, err := collectYAMLFiles(args)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		results := []lintResult{}
+		for _, filename := range filenames {
+			content, err := os.ReadFile(filename)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			results = append(results, lintFile(filename, content)...)
+		}
+
+		out, err := format(results)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		fmt.Print(string(out))
+
+		for _, result := range results {
+			if result.Severity == sealer.LintSeverityError {
+				os.Exit(exitCodeFailure)
+			}
+		}
+	},
+}
+
+// lint SealedSecrets in the file. only SealedSecrets can have errors.
+func lintFile(filename string, content []byte) []lintResult {
+	results := []lintResult{}
+	for _, doc := range sealer.SplitYAMLDocuments(content) {
+		kind, err := sealer.DocumentKind(doc.Content)
+		if err != nil {
+			// we can't tell whether it's a SealedSecret, but broken yaml is worth reporting anyway.
+			// it's only a warning, so that broken manifests of other tools don't fail the lint
+			finding := sealer.LintFinding{Rule: "yaml", Severity: sealer.LintSeverityWarning, Message: err.Error()}
+			results = append(results, lintResult{LintFinding: finding, File: filename, Line: doc.Line})
+			continue
+		}
+		if kind != "SealedSecret" {
+			continue
+		}
+		for _, finding := range sealer.LintSealedSecretYAML(doc.Content) {
+			results = append(results, lintResult{LintFinding: finding, File: filename, Line: doc.Line})
+		}
+	}
+	return results
+}
+
+type lintResult struct {
+	sealer.LintFinding
+	File string `json:"file"`
+	// line where the document containing the finding starts
+	Line int `json:"line"`
+}
+
+// expand directories into *.yaml and *.yml files within them
+func collectYAMLFiles(paths []string) ([]string, error) {
+	filenames := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			filenames = append(filenames, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (strings.HasSuffix(p, ".yaml") || strings.HasSuffix(p, ".yml")) {
+				filenames = append(filenames, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filenames, nil
+}
+
+func formatLintResultsText(results []lintResult) ([]byte, error) {
+	var b strings.Builder
+	for _, r := range results {
+		location := r.Field
+		if location != "" {
+			location += ": "
+		}
+		fmt.Fprintf(&b, "%s:%d: %s: [%s] %s%s\n", r.File, r.Line, r.Severity, r.Rule, location, r.Message)
+	}
+	return []byte(b.String()), nil
+}
+
+func formatLintResultsJSON(results []lintResult) ([]byte, error) {
+	out, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// minimal subset of SARIF 2.1.0, which is enough for GitHub code scanning and most CI annotations
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func formatLintResultsSARIF(results []lintResult) ([]byte, error) {
+	rules := []sarifRule{}
+	for _, id := range sealer.SortedKeys(sealer.LintRules) {
+		rules = append(rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: sealer.LintRules[id]}})
+	}
+
+	sarifResults := []sarifResult{}
+	for _, r := range results {
+		message := r.Message
+		if r.Field != "" {
+			message = r.Field + ": " + message
+		}
+		sarifResults = append(sarifResults, sarifResult{
+			RuleID:  r.Rule,
+			Level:   r.Severity,
+			Message: sarifMessage{Text: message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(r.File)},
+					Region:           sarifRegion{StartLine: r.Line},
+				},
+			}},
+		})
+	}
+
+	sarif := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "kubectl-sealer",
+				Version:        Version,
+				InformationURI: "https://github.com/shusugmt/kubectl-sealer",
+				Rules:          rules,
+			}},
+			Results: sarifResults,
+		}},
+	}
+	out, err := json.MarshalIndent(sarif, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
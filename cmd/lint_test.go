@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+)
+
+func TestLintFile(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		// rule and severity of each result
+		expected [][2]string
+	}{
+		"other kinds": {
+			content:  "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: foo\n---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: bar\n",
+			expected: [][2]string{},
+		},
+		"broken yaml": {
+			content:  "apiVersion: v1\nkind: ConfigMap\ndata: [\n",
+			expected: [][2]string{{"yaml", sealer.LintSeverityWarning}},
+		},
+		"broken SealedSecret": {
+			content:  "apiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: foo\nspec: []\n",
+			expected: [][2]string{{"parse", sealer.LintSeverityError}},
+		},
+		"broken yaml among SealedSecrets": {
+			content:  "{{ .Values.secret }}: [\n---\napiVersion: bitnami.com/v1alpha1\nkind: SealedSecret\nmetadata:\n  name: foo\nspec: []\n",
+			expected: [][2]string{{"yaml", sealer.LintSeverityWarning}, {"parse", sealer.LintSeverityError}},
+		},
+	}
+
+	for name, test := range tests {
+		results := lintFile("manifest.yaml", []byte(test.content))
+		if len(results) != len(test.expected) {
+			t.Errorf("%v: expected %v, got %+v", name, test.expected, results)
+			continue
+		}
+		for i, result := range results {
+			if result.Rule != test.expected[i][0] || result.Severity != test.expected[i][1] {
+				t.Errorf("%v: expected %v, got %+v", name, test.expected[i], result)
+			}
+			if result.File != "manifest.yaml" {
+				t.Errorf("%v: expected file name, got %q", name, result.File)
+			}
+		}
+	}
+}
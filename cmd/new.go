@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"log"
-	"os"
 
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
 	"github.com/shusugmt/kubectl-sealer/sealer"
@@ -14,24 +13,41 @@ import (
 )
 
 type newCmdOptions struct {
-	filename   string
-	name       string
-	namespace  string
-	secretType string
-	scope      string
+	filename     string
+	name         string
+	namespace    string
+	secretType   string
+	scope        string
+	backupSuffix string
+	template     string
+	edit         bool
+	scaffold     sealer.ScaffoldOptions
 }
 
 var newCmdOpts = &newCmdOptions{}
 
 func init() {
 	addFlagFilename(newCmd, &newCmdOpts.filename, false)
+	addFlagBackup(newCmd, &newCmdOpts.backupSuffix)
 
 	newCmd.Flags().StringVar(&newCmdOpts.name, "name", "", "name of the base Secret resource")
 	newCmd.Flags().StringVar(&newCmdOpts.namespace, "namespace", corev1.NamespaceDefault, "namespace of the base Secret resource")
 	newCmd.Flags().StringVar(&newCmdOpts.secretType, "type", string(corev1.SecretTypeOpaque), "type of the base Secret resource")
+	newCmd.Flags().StringVar(&newCmdOpts.template, "template", "", "name of the template to start from, found in templates directory of .sealer or the user config directory")
 
 	defaultScope := ssv1alpha1.DefaultScope
 	newCmd.Flags().StringVar(&newCmdOpts.scope, "scope", defaultScope.String(), "set the scope of the sealed secret")
+
+	// type specific values; the type is inferred from them unless --type is given
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.CertFile, "cert-file", "", "PEM encoded certificate for kubernetes.io/tls")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.KeyFile, "key-file", "", "PEM encoded private key for kubernetes.io/tls")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.DockerServer, "docker-server", "", "registry server for kubernetes.io/dockerconfigjson")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.DockerUsername, "docker-username", "", "registry username for kubernetes.io/dockerconfigjson")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.DockerPassword, "docker-password", "", "registry password for kubernetes.io/dockerconfigjson; prompted if omitted")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.DockerEmail, "docker-email", "", "registry email for kubernetes.io/dockerconfigjson")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.Username, "username", "", "username for kubernetes.io/basic-auth; password is prompted")
+	newCmd.Flags().StringVar(&newCmdOpts.scaffold.SSHKeyFile, "ssh-key-file", "", "private key for kubernetes.io/ssh-auth")
+	newCmd.Flags().BoolVar(&newCmdOpts.edit, "edit", false, "open editor even if the values are given by flags")
 }
 
 var newCmd = &cobra.Command{
@@ -40,34 +56,75 @@ var newCmd = &cobra.Command{
 	Long:  `Create a new SealedSecret.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		emptySecretYAML, err := generateEmptySecret(newCmdOpts.name, newCmdOpts.namespace, newCmdOpts.secretType, newCmdOpts.scope)
+		scaffoldType, err := newCmdOpts.scaffold.SecretType()
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
+		if newCmdOpts.template != "" && (scaffoldType != "" || cmd.Flags().Changed("type")) {
+			log.Fatalf("--template cannot be used with --type or type specific flags")
+		}
 
-		editedSecretYAML, err := sealer.EditSecretUntilOK(emptySecretYAML)
-		if err != nil {
-			log.Fatalf("%v", err)
+		var baseSecret *corev1.Secret
+		if scaffoldType != "" {
+			secretType := scaffoldType
+			if cmd.Flags().Changed("type") {
+				secretType = corev1.SecretType(newCmdOpts.secretType)
+			}
+			stringData, err := scaffoldSecretData(secretType, newCmdOpts.scaffold)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			baseSecret = &corev1.Secret{Type: secretType, StringData: stringData}
+		} else {
+			templateName := newCmdOpts.template
+			if templateName == "" {
+				templateName, err = sealer.TemplateNameForType(corev1.SecretType(newCmdOpts.secretType))
+				if err != nil {
+					log.Fatalf("%v", err)
+				}
+			}
+			baseSecret, err = secretFromTemplate(templateName, newCmdOpts.name, newCmdOpts.namespace)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
 		}
 
-		newSealedSecretYAML, err := sealer.Seal(editedSecretYAML, false)
+		namespace := ""
+		if cmd.Flags().Changed("namespace") {
+			namespace = newCmdOpts.namespace
+		}
+		emptySecretYAML, err := generateEmptySecret(baseSecret, newCmdOpts.name, namespace, newCmdOpts.scope)
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
 
-		if newCmdOpts.filename != "" {
-			f, err := os.Create(newCmdOpts.filename)
+		var editedSecretYAML []byte
+		if scaffoldType != "" && !newCmdOpts.edit {
+			// everything is given by flags, so there's nothing to edit
+			if newCmdOpts.name == "" {
+				log.Fatalf("--name is required unless editing the Secret")
+			}
+			err = sealer.CheckSecretYAML(emptySecretYAML, loadPolicy())
 			if err != nil {
-				log.Fatalf("failed opening file to overwrite with new SealedSecret: %s: %v", f.Name(), err)
+				log.Fatalf("validation failed: %v", err)
 			}
-			_, err = f.Write(newSealedSecretYAML)
+			editedSecretYAML = emptySecretYAML
+		} else {
+			editedSecretYAML, err = sealer.EditSecretUntilOK(emptySecretYAML, rootCmdOpts.editor, loadPolicy())
 			if err != nil {
-				f.Close()
-				log.Fatalf("failed writing new SealedSecret: %s: %v", f.Name(), err)
+				exitWithEditError(err)
 			}
-			err = f.Close()
+		}
+
+		newSealedSecretYAML, err := sealer.Seal(editedSecretYAML, false, sealer.Target{})
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		if newCmdOpts.filename != "" {
+			err = sealer.WriteFileAtomic(newCmdOpts.filename, newSealedSecretYAML, 0644, newCmdOpts.backupSuffix)
 			if err != nil {
-				log.Fatalf("failed writing new SealedSecret: %s: %v", f.Name(), err)
+				log.Fatalf("failed writing new SealedSecret: %v", err)
 			}
 		} else {
 			fmt.Print(string(newSealedSecretYAML))
@@ -75,96 +132,80 @@ var newCmd = &cobra.Command{
 	},
 }
 
-func generateEmptySecret(name string, namespace string, secretTypeString string, scopeString string) (emptySecretYAML []byte, err error) {
-
-	secret := corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
-		},
-		ObjectMeta: metav1.ObjectMeta{
-			Name:        "changeme",
-			Namespace:   namespace,
-			Annotations: map[string]string{},
-		},
-		Type: corev1.SecretTypeOpaque,
-	}
-
-	if name != "" {
-		secret.ObjectMeta.Name = name
+// prompt for passwords which are not given by flags, so that they don't end up in shell history
+func scaffoldSecretData(secretType corev1.SecretType, o sealer.ScaffoldOptions) (map[string]string, error) {
+	var err error
+	switch secretType {
+	case corev1.SecretTypeBasicAuth:
+		if o.Username != "" && o.Password == "" {
+			o.Password, err = sealer.PromptSecret("password for " + o.Username)
+		}
+	case corev1.SecretTypeDockerConfigJson:
+		if o.DockerUsername != "" && o.DockerPassword == "" {
+			o.DockerPassword, err = sealer.PromptSecret("password for " + o.DockerUsername)
+		}
 	}
-
-	var scope ssv1alpha1.SealingScope
-	err = scope.Set(scopeString)
 	if err != nil {
-		return nil, fmt.Errorf("failed setting scope: \"%s\": %v", scopeString, err)
+		return nil, err
 	}
-	ssv1alpha1.UpdateScopeAnnotations(secret.GetObjectMeta().GetAnnotations(), scope)
+	return sealer.ScaffoldSecretData(secretType, o)
+}
 
-	secret.Type, err = checkSecretType(secretTypeString)
+// render the template into a Secret, which is completed by generateEmptySecret
+func secretFromTemplate(templateName string, name string, namespace string) (*corev1.Secret, error) {
+	content, err := sealer.ReadTemplate(templateName)
 	if err != nil {
 		return nil, err
 	}
-	fillWithPlaceholders(&secret)
-
-	emptySecretYAML, err = yaml.Marshal(secret)
+	secretYAML, err := sealer.RenderSecretTemplate(templateName, content, sealer.TemplateValues{Name: name, Namespace: namespace})
 	if err != nil {
-		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
+		return nil, fmt.Errorf("template %s: %v", templateName, err)
 	}
-	return emptySecretYAML, nil
-}
-
-func checkSecretType(secretTypeString string) (corev1.SecretType, error) {
-	switch secretTypeString {
-	case string(corev1.SecretTypeOpaque):
-		return corev1.SecretTypeOpaque, nil
-	case string(corev1.SecretTypeServiceAccountToken):
-		return corev1.SecretTypeServiceAccountToken, nil
-	case string(corev1.SecretTypeDockercfg):
-		return corev1.SecretTypeDockercfg, nil
-	case string(corev1.SecretTypeDockerConfigJson):
-		return corev1.SecretTypeDockerConfigJson, nil
-	case string(corev1.SecretTypeBasicAuth):
-		return corev1.SecretTypeBasicAuth, nil
-	case string(corev1.SecretTypeSSHAuth):
-		return corev1.SecretTypeSSHAuth, nil
-	case string(corev1.SecretTypeTLS):
-		return corev1.SecretTypeTLS, nil
-	case string(corev1.SecretTypeBootstrapToken):
-		return corev1.SecretTypeBootstrapToken, nil
-	default:
-		return "", fmt.Errorf("no such type exists for Secret: \"%s\"", secretTypeString)
+	var secret corev1.Secret
+	err = yaml.UnmarshalStrict(secretYAML, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: error unmarshalling yaml to kubernetes Secret: %v", templateName, err)
 	}
+	return &secret, nil
 }
 
-func fillWithPlaceholders(secret *corev1.Secret) {
-	secret.StringData = map[string]string{}
-	switch secret.Type {
-	case corev1.SecretTypeServiceAccountToken:
-		secret.StringData[corev1.ServiceAccountTokenKey] = "changeme"
+// fill in metadata of the Secret. name and namespace are taken from the base Secret if empty.
+func generateEmptySecret(secret *corev1.Secret, name string, namespace string, scopeString string) (emptySecretYAML []byte, err error) {
 
-		secret.Annotations = map[string]string{}
-		secret.Annotations[corev1.ServiceAccountNameKey] = "changeme"
-		secret.Annotations[corev1.ServiceAccountUIDKey] = "changeme"
-
-	case corev1.SecretTypeDockercfg:
-		secret.StringData[corev1.DockerConfigKey] = "changeme"
-
-	case corev1.SecretTypeDockerConfigJson:
-		secret.StringData[corev1.DockerConfigJsonKey] = "changeme"
-
-	case corev1.SecretTypeBasicAuth:
-		secret.StringData[corev1.BasicAuthUsernameKey] = "changeme"
-		secret.StringData[corev1.BasicAuthPasswordKey] = "changeme"
+	secret.TypeMeta = metav1.TypeMeta{
+		APIVersion: "v1",
+		Kind:       "Secret",
+	}
 
-	case corev1.SecretTypeSSHAuth:
-		secret.StringData[corev1.SSHAuthPrivateKey] = "changeme"
+	if name != "" {
+		secret.Name = name
+	}
+	if secret.Name == "" {
+		secret.Name = "changeme"
+	}
+	if namespace != "" {
+		secret.Namespace = namespace
+	}
+	if secret.Namespace == "" {
+		secret.Namespace = corev1.NamespaceDefault
+	}
+	if secret.Type == "" {
+		secret.Type = corev1.SecretTypeOpaque
+	}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
 
-	case corev1.SecretTypeTLS:
-		secret.StringData[corev1.TLSCertKey] = "changeme"
-		secret.StringData[corev1.TLSPrivateKeyKey] = "changeme"
+	var scope ssv1alpha1.SealingScope
+	err = scope.Set(scopeString)
+	if err != nil {
+		return nil, fmt.Errorf("failed setting scope: \"%s\": %v", scopeString, err)
+	}
+	ssv1alpha1.UpdateScopeAnnotations(secret.GetObjectMeta().GetAnnotations(), scope)
 
-	default:
-		secret.StringData["change"] = "me"
+	emptySecretYAML, err = yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
 	}
+	return emptySecretYAML, nil
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"io"
+	"log"
+	"os"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type helmPostRenderCmdOptions struct {
+	selector  string
+	names     []string
+	namespace string
+	cert      string
+}
+
+var helmPostRenderCmdOpts = &helmPostRenderCmdOptions{}
+
+func init() {
+	helmPostRenderCmd.Flags().StringVarP(&helmPostRenderCmdOpts.selector, "selector", "l", "", "seal only Secrets matching this label selector, e.g. app=foo")
+	helmPostRenderCmd.Flags().StringSliceVar(&helmPostRenderCmdOpts.names, "name", nil, "seal only Secrets with these names; glob patterns are allowed")
+	helmPostRenderCmd.Flags().StringVarP(&helmPostRenderCmdOpts.namespace, "namespace", "n", "", "namespace of Secrets without one, i.e. the release namespace; required for them unless sealed cluster-wide")
+	helmPostRenderCmd.Flags().StringVar(&helmPostRenderCmdOpts.cert, "cert", "", "certificate file or URL to seal with offline, instead of fetching it from the controller")
+}
+
+var helmPostRenderCmd = &cobra.Command{
+	Use:   "helm-post-render",
+	Short: "seal Secrets in manifests rendered by helm, as a post renderer",
+	Long: `Seal Secrets in manifests rendered by helm, as a post renderer.
+
+Reads multi-document YAML from stdin, and replaces Secrets with SealedSecrets, which keep their
+labels and annotations in spec.template. Other documents are passed through unchanged.
+Secrets without namespace are put in --namespace, which must be the release namespace:
+
+  helm install app chart/ -n app --post-renderer kubectl-sealer \
+    --post-renderer-args helm-post-render --post-renderer-args --namespace=app`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		filter := sealer.SecretFilter{Names: helmPostRenderCmdOpts.names}
+		if helmPostRenderCmdOpts.selector != "" {
+			selector, err := labels.Parse(helmPostRenderCmdOpts.selector)
+			if err != nil {
+				log.Fatalf("invalid selector: %v", err)
+			}
+			filter.Selector = selector
+		}
+
+		manifests, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		output, sealed, err := sealRenderedManifests(manifests, filter)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		os.Stdout.Write(output)
+		log.Printf("sealed %d Secrets", sealed)
+	},
+}
+
+// seal Secrets in the manifests, enforcing the policy the same as edit does
+func sealRenderedManifests(manifests []byte, filter sealer.SecretFilter) ([]byte, int, error) {
+	policy := loadPolicy()
+	target := sealer.Target{Cert: helmPostRenderCmdOpts.cert}
+	return sealer.SealSecretsInManifests(manifests, filter, helmPostRenderCmdOpts.namespace, func(secretYAML []byte) ([]byte, error) {
+		err := sealer.CheckSecretYAML(secretYAML, policy)
+		if err != nil {
+			return nil, err
+		}
+		return sealer.Seal(secretYAML, false, target)
+	})
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+)
+
+func TestSealRenderedManifests(t *testing.T) {
+	installStubs(t)
+	usePolicy(t, "rules:\n- name: no passwords\n  forbiddenKeys: [\"*password*\"]\n")
+	tlsCrt, tlsKey := testTLSData(t)
+
+	// chart templates usually put values in data with b64enc
+	tests := map[string]struct {
+		manifests     string
+		expectedError string
+	}{
+		"tls in data": {
+			manifests: fmt.Sprintf("---\n# Source: chart/templates/tls.yaml\napiVersion: v1\nkind: Secret\ntype: kubernetes.io/tls\nmetadata:\n  name: foo\n  namespace: app\ndata:\n  tls.crt: %s\n  tls.key: %s\n", tlsCrt, tlsKey),
+		},
+		"forbidden key in data": {
+			manifests:     "apiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\n  namespace: app\ndata:\n  db-password: aHVudGVyMg==\n",
+			expectedError: "data[db-password]: Forbidden",
+		},
+	}
+
+	for name, test := range tests {
+		output, sealed, err := sealRenderedManifests([]byte(test.manifests), sealer.SecretFilter{})
+		if test.expectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("%v: expected error %q, got: %v", name, test.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if sealed != 1 || !strings.Contains(string(output), "kind: SealedSecret") {
+			t.Errorf("%v: expected the Secret to be sealed, got:\n%s", name, output)
+		}
+	}
+}
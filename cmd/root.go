@@ -1,20 +1,46 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/shusugmt/kubectl-sealer/sealer"
 	"github.com/spf13/cobra"
 )
 
+// exit codes, so that scripts can tell a canceled edit apart from an actual failure
+const (
+	exitCodeFailure  = 1
+	exitCodeCanceled = 2
+)
+
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+		os.Exit(exitCodeFailure)
+	}
+}
+
+// like log.Fatalf, but exits with exitCodeCanceled when the user canceled editing
+func exitWithEditError(err error) {
+	if errors.Is(err, sealer.ErrEditCanceled) {
+		log.Printf("%v", err)
+		os.Exit(exitCodeCanceled)
 	}
+	log.Fatalf("%v", err)
 }
 
+type rootCmdOptions struct {
+	editor       string
+	policy       string
+	clusters     string
+	passphraseFD int
+}
+
+var rootCmdOpts = &rootCmdOptions{}
+
 func addFlagFilename(cmd *cobra.Command, storeTo *string, required bool) {
 	cmd.Flags().StringVarP(storeTo, "filename", "f", "", "path to SealedSecret resource")
 	cmd.MarkFlagFilename("filename")
@@ -23,6 +49,100 @@ func addFlagFilename(cmd *cobra.Command, storeTo *string, required bool) {
 	}
 }
 
+func addFlagBackup(cmd *cobra.Command, storeTo *string) {
+	cmd.Flags().StringVar(storeTo, "backup", "", "keep the original file with this suffix when overwriting it (\"~\" if given without value)")
+	cmd.Flags().Lookup("backup").NoOptDefVal = "~"
+}
+
+func addFlagPrivateKey(cmd *cobra.Command, storeTo *[]string) {
+	cmd.Flags().StringSliceVar(storeTo, "private-key", nil, "decrypt with these local private keys instead of the ones in the cluster; PEM files, Secrets or backups by `keys backup`, possibly passphrase encrypted")
+	cmd.MarkFlagFilename("private-key")
+}
+
+// where Unseal gets the sealing keys from; the controller namespace, or local private keys if given
+func unsealOptions(controllerNamespace string, privateKeyFiles []string) sealer.UnsealOptions {
+	return sealer.UnsealOptions{
+		Target:          sealer.Target{ControllerNamespace: controllerNamespace},
+		PrivateKeyFiles: privateKeyFiles,
+		Passphrase: func() (string, error) {
+			return sealer.ReadPassphrase(rootCmdOpts.passphraseFD, false)
+		},
+	}
+}
+
+func addFlagFor(cmd *cobra.Command, storeTo *[]string) {
+	cmd.Flags().StringSliceVar(storeTo, "for", nil, "aliases of clusters defined in "+sealer.ClustersFileName+" to seal for, e.g. prod-eu,prod-us")
+}
+
+// load clusters of the given aliases from the file given by --clusters, or from configuration directories.
+// controller namespace defaults to the given one, since getting sealing keys requires it.
+func loadClusters(aliases []string, defaultControllerNamespace string) []*sealer.Cluster {
+	config, err := sealer.LoadClusters(rootCmdOpts.clusters)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if config == nil {
+		log.Fatalf("no clusters defined; create %s in .sealer/ or user config dir, or give --clusters", sealer.ClustersFileName)
+	}
+	clusters, err := config.Lookup(aliases)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	for _, cluster := range clusters {
+		if cluster.ControllerNamespace == "" {
+			cluster.ControllerNamespace = defaultControllerNamespace
+		}
+	}
+	return clusters
+}
+
+func addFlagDigests(cmd *cobra.Command, storeTo *bool) {
+	cmd.Flags().BoolVar(storeTo, "digests", false, "annotate the SealedSecret with HMAC-SHA256 digests of the values keyed by $"+sealer.DigestKeyEnv+" or "+sealer.DigestKeyFileName+", so that changes can be told without decrypting; kept up to date once present")
+}
+
+// returns the HMAC key for digests if they are requested, or the source SealedSecret already has them,
+// otherwise nil. it's loaded before anything is edited, so that a missing key doesn't waste the work.
+func loadDigestKey(requested bool, srcSealedSecretYAML []byte) []byte {
+	if !requested && (srcSealedSecretYAML == nil || !sealer.HasDigestAnnotation(srcSealedSecretYAML)) {
+		return nil
+	}
+	key, err := sealer.LoadDigestKey()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if key == nil {
+		log.Fatalf("no digest key; set $%s or create %s in .sealer/ or user config dir", sealer.DigestKeyEnv, sealer.DigestKeyFileName)
+	}
+	return key
+}
+
+// whether the SealedSecret must be updated to add digests, even if the values don't change
+func missingDigests(digestKey []byte, srcSealedSecretYAML []byte) bool {
+	return digestKey != nil && !sealer.HasDigestAnnotation(srcSealedSecretYAML)
+}
+
+// annotate the SealedSecret with digests of the plain Secret, unless digestKey is nil
+func annotateDigests(digestKey []byte, sealedSecretYAML []byte, secretYAML []byte) []byte {
+	if digestKey == nil {
+		return sealedSecretYAML
+	}
+	annotatedYAML, err := sealer.AnnotateDigests(sealedSecretYAML, secretYAML, digestKey)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return annotatedYAML
+}
+
+// load the policy given by --policy, or from configuration directories.
+// returns nil if there is no policy, which accepts everything.
+func loadPolicy() *sealer.Policy {
+	policy, err := sealer.LoadPolicy(rootCmdOpts.policy)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	return policy
+}
+
 func setSealedSecretsControllerNamespace(storeTo *string) {
 	// default to kube-system, consistent with kubeseal
 	*storeTo = sealer.GetEnv("SEALED_SECRETS_CONTROLLER_NAMESPACE", "kube-system")
@@ -38,9 +158,23 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.editor, "editor", "", "editor command to use, may include arguments like \"code --wait\" (default $KUBE_EDITOR, $VISUAL, $EDITOR or vi)")
+
+	rootCmd.PersistentFlags().IntVar(&rootCmdOpts.passphraseFD, "passphrase-fd", -1, "read passphrase of encrypted private keys from this file descriptor instead of the terminal (also $"+sealer.PassphraseEnv+")")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.clusters, "clusters", "", "path to file defining cluster aliases for --for (default "+sealer.ClustersFileName+" in .sealer/ or user config dir)")
+	rootCmd.PersistentFlags().StringVar(&rootCmdOpts.policy, "policy", "", "path to policy file which Secrets must satisfy before sealing (default "+sealer.PolicyFileName+" in .sealer/ or user config dir)")
+
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(editCmd)
 	rootCmd.AddCommand(newCmd)
+	rootCmd.AddCommand(setCmd)
+	rootCmd.AddCommand(sealCmd)
 	rootCmd.AddCommand(genkeyCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(certCmd)
+	rootCmd.AddCommand(lintCmd)
+	rootCmd.AddCommand(krmCmd)
+	rootCmd.AddCommand(helmPostRenderCmd)
+	rootCmd.AddCommand(argocdGenerateCmd)
 }
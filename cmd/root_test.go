@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+)
+
+func TestExitWithEditError(t *testing.T) {
+	// exitWithEditError never returns, so it's run in a child process of the test binary
+	if errorName := os.Getenv("TEST_EXIT_WITH_EDIT_ERROR"); errorName != "" {
+		errs := map[string]error{
+			"canceled": sealer.ErrEditCanceled,
+			"wrapped":  fmt.Errorf("cluster prod: %w", sealer.ErrEditCanceled),
+			"failure":  errors.New("error invoking editor"),
+		}
+		exitWithEditError(errs[errorName])
+		return
+	}
+
+	tests := map[string]struct {
+		expectedExitCode int
+	}{
+		"canceled": {expectedExitCode: exitCodeCanceled},
+		"wrapped":  {expectedExitCode: exitCodeCanceled},
+		"failure":  {expectedExitCode: exitCodeFailure},
+	}
+
+	for name, test := range tests {
+		command := exec.Command(os.Args[0], "-test.run=^TestExitWithEditError$")
+		command.Env = append(os.Environ(), "TEST_EXIT_WITH_EDIT_ERROR="+name)
+		err := command.Run()
+		var exitError *exec.ExitError
+		if !errors.As(err, &exitError) {
+			t.Errorf("%v: expected non-zero exit, got: %v", name, err)
+			continue
+		}
+		if exitError.ExitCode() != test.expectedExitCode {
+			t.Errorf("%v: expected exit code %d, got %d", name, test.expectedExitCode, exitError.ExitCode())
+		}
+	}
+}
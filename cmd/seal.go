@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type sealCmdOptions struct {
+	filename                         string
+	sealedSecretsControllerNamespace string
+	clusters                         []string
+	backupSuffix                     string
+	digests                          bool
+}
+
+var sealCmdOpts = &sealCmdOptions{}
+
+func init() {
+	sealCmd.Flags().StringVarP(&sealCmdOpts.filename, "filename", "f", "", "path to Secret resource to seal, or - for stdin")
+	sealCmd.MarkFlagFilename("filename")
+	sealCmd.MarkFlagRequired("filename")
+	setSealedSecretsControllerNamespace(&sealCmdOpts.sealedSecretsControllerNamespace)
+	addFlagFor(sealCmd, &sealCmdOpts.clusters)
+	addFlagBackup(sealCmd, &sealCmdOpts.backupSuffix)
+	addFlagDigests(sealCmd, &sealCmdOpts.digests)
+}
+
+var sealCmd = &cobra.Command{
+	Use:   "seal",
+	Short: "seal plain Secret, possibly for several clusters at once",
+	Long: `Seal plain Secret, possibly for several clusters at once.
+
+With --for, the Secret is sealed for each of the clusters defined in clusters.yaml, and written
+to their output paths, or printed if the cluster has none. Generated values are generated once,
+so every cluster gets the same plaintext:
+
+  kubectl sealer seal -f secret.yaml --for prod-eu,prod-us,staging
+
+Nothing is written unless sealing succeeded for all of the clusters.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+
+		var secretYAML []byte
+		var err error
+		if sealCmdOpts.filename == "-" {
+			secretYAML, err = io.ReadAll(os.Stdin)
+		} else {
+			secretYAML, err = os.ReadFile(sealCmdOpts.filename)
+		}
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		digestKey := loadDigestKey(sealCmdOpts.digests, nil)
+
+		secretYAML, err = sealer.ExpandGenerators(secretYAML)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		secretYAML, err = sealer.ApplyDerivations(secretYAML)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		err = sealer.CheckSecretYAML(secretYAML, loadPolicy())
+		if err != nil {
+			log.Fatalf("validation failed: %v", err)
+		}
+
+		if len(sealCmdOpts.clusters) == 0 {
+			sealedSecretYAML, err := sealer.Seal(secretYAML, false, sealer.Target{})
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+			fmt.Print(string(annotateDigests(digestKey, sealedSecretYAML, secretYAML)))
+			return
+		}
+
+		var secret corev1.Secret
+		err = yaml.Unmarshal(secretYAML, &secret)
+		if err != nil {
+			log.Fatalf("error unmarshalling yaml to kubernetes Secret: %v", err)
+		}
+
+		results := []clusterSealedSecret{}
+		for _, cluster := range loadClusters(sealCmdOpts.clusters, sealCmdOpts.sealedSecretsControllerNamespace) {
+			result := clusterSealedSecret{cluster: cluster}
+			if cluster.Output != "" {
+				result.path, err = cluster.OutputPath(secret.Name, secret.Namespace)
+				if err != nil {
+					log.Fatalf("%v", err)
+				}
+			}
+			result.sealedSecretYAML, err = sealer.Seal(secretYAML, false, cluster.Target())
+			if err != nil {
+				log.Fatalf("cluster %s: %v", cluster.Alias, err)
+			}
+			result.sealedSecretYAML = annotateDigests(digestKey, result.sealedSecretYAML, secretYAML)
+			results = append(results, result)
+		}
+		writeClusterSealedSecrets(results, sealCmdOpts.backupSuffix)
+	},
+}
+
+// SealedSecret sealed for a cluster, and where it goes
+type clusterSealedSecret struct {
+	cluster *sealer.Cluster
+	// output path of the cluster; printed to stdout if empty
+	path             string
+	sealedSecretYAML []byte
+}
+
+// write SealedSecrets to their output paths, and print the rest as a multi-document YAML
+func writeClusterSealedSecrets(results []clusterSealedSecret, backupSuffix string) {
+	printed := 0
+	for _, result := range results {
+		if result.path == "" {
+			if printed > 0 {
+				fmt.Println("---")
+			}
+			fmt.Print(string(result.sealedSecretYAML))
+			printed++
+			continue
+		}
+
+		err := os.MkdirAll(filepath.Dir(result.path), 0755)
+		if err != nil {
+			log.Fatalf("error creating output directory: %v", err)
+		}
+		err = sealer.WriteFileAtomic(result.path, result.sealedSecretYAML, 0644, backupSuffix)
+		if err != nil {
+			log.Fatalf("failed writing SealedSecret for %s: %v", result.cluster.Alias, err)
+		}
+		log.Printf("%s: wrote %s", result.cluster.Alias, result.path)
+	}
+}
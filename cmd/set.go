@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/shusugmt/kubectl-sealer/sealer"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+type setCmdOptions struct {
+	filename                         string
+	sealedSecretsControllerNamespace string
+	privateKeyFiles                  []string
+	inPlace                          bool
+	backupSuffix                     string
+	digests                          bool
+}
+
+var setCmdOpts = &setCmdOptions{}
+
+func init() {
+	addFlagFilename(setCmd, &setCmdOpts.filename, true)
+	setSealedSecretsControllerNamespace(&setCmdOpts.sealedSecretsControllerNamespace)
+	addFlagPrivateKey(setCmd, &setCmdOpts.privateKeyFiles)
+	setCmd.Flags().BoolVarP(&setCmdOpts.inPlace, "in-place", "i", false, "overwrite the input SealedSecret file with updated content")
+	addFlagBackup(setCmd, &setCmdOpts.backupSuffix)
+	addFlagDigests(setCmd, &setCmdOpts.digests)
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set KEY=VALUE...",
+	Short: "set values of SealedSecret without opening editor",
+	Long: `Set values of SealedSecret without opening editor. Only the given keys are re-encrypted.
+
+Values can be generated instead of typed, so that plaintext never shows up in shell history:
+
+  kubectl sealer set -f secret.yaml -i 'password=!generate 32 alnum' 'client-id=!generate uuid'
+  kubectl sealer set -f secret.yaml -i 'auth={{ htpasswd "admin" (generate 24 "alnum") }}'`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+
+		keyValues := map[string]string{}
+		for _, arg := range args {
+			parts := strings.SplitN(arg, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				log.Fatalf("invalid argument: %q, must be KEY=VALUE", arg)
+			}
+			keyValues[parts[0]] = parts[1]
+		}
+
+		srcSealedSecretYAML, err := os.ReadFile(setCmdOpts.filename)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		digestKey := loadDigestKey(setCmdOpts.digests, srcSealedSecretYAML)
+
+		srcSecretYAML, err := sealer.Unseal(srcSealedSecretYAML, unsealOptions(setCmdOpts.sealedSecretsControllerNamespace, setCmdOpts.privateKeyFiles))
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		var secret corev1.Secret
+		err = yaml.UnmarshalStrict(srcSecretYAML, &secret)
+		if err != nil {
+			log.Fatalf("error unmarshalling yaml to kubernetes Secret: %v", err)
+		}
+		derivedKeys, err := sealer.DerivedKeys(secret.Annotations)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		for _, k := range derivedKeys {
+			if _, ok := keyValues[k]; ok {
+				log.Fatalf("%s is derived from other keys, set them instead", k)
+			}
+		}
+		if secret.StringData == nil {
+			secret.StringData = map[string]string{}
+		}
+		for k, v := range keyValues {
+			secret.StringData[k] = v
+		}
+		setSecretYAML, err := yaml.Marshal(secret)
+		if err != nil {
+			log.Fatalf("error marshalling kubernetes Secret to YAML: %v", err)
+		}
+
+		editedSecretYAML, err := sealer.ExpandGenerators(setSecretYAML)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		editedSecretYAML, err = sealer.ApplyDerivations(editedSecretYAML)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		err = sealer.CheckSecretYAML(editedSecretYAML, loadPolicy())
+		if err != nil {
+			log.Fatalf("validation failed: %v", err)
+		}
+
+		if bytes.Equal(editedSecretYAML, srcSecretYAML) && !missingDigests(digestKey, srcSealedSecretYAML) {
+			fmt.Fprintln(os.Stderr, "no change")
+			os.Exit(0)
+		}
+
+		updatedSealedSecretYAML, err := updateSealedSecret(srcSealedSecretYAML, srcSecretYAML, editedSecretYAML, false, sealer.Target{})
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		updatedSealedSecretYAML = annotateDigests(digestKey, updatedSealedSecretYAML, editedSecretYAML)
+
+		if setCmdOpts.inPlace {
+			err = sealer.WriteFileAtomic(setCmdOpts.filename, updatedSealedSecretYAML, 0644, setCmdOpts.backupSuffix)
+			if err != nil {
+				log.Fatalf("failed writing updated SealedSecret: %v", err)
+			}
+		} else {
+			fmt.Print(string(updatedSealedSecretYAML))
+		}
+	},
+}
@@ -12,6 +12,7 @@ import (
 type showCmdOptions struct {
 	filename                         string
 	sealedSecretsControllerNamespace string
+	privateKeyFiles                  []string
 }
 
 var showCmdOpts = &showCmdOptions{}
@@ -19,6 +20,7 @@ var showCmdOpts = &showCmdOptions{}
 func init() {
 	addFlagFilename(showCmd, &showCmdOpts.filename, true)
 	setSealedSecretsControllerNamespace(&showCmdOpts.sealedSecretsControllerNamespace)
+	addFlagPrivateKey(showCmd, &showCmdOpts.privateKeyFiles)
 }
 
 var showCmd = &cobra.Command{
@@ -32,7 +34,7 @@ var showCmd = &cobra.Command{
 			log.Fatalf("%v", err)
 		}
 
-		s, err := sealer.Unseal(sealedSecretYAML, showCmdOpts.sealedSecretsControllerNamespace)
+		s, err := sealer.Unseal(sealedSecretYAML, unsealOptions(showCmdOpts.sealedSecretsControllerNamespace, showCmdOpts.privateKeyFiles))
 		if err != nil {
 			log.Fatalf("%v", err)
 		}
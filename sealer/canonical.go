@@ -0,0 +1,57 @@
+package sealer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	"sigs.k8s.io/yaml"
+)
+
+// rewrite SealedSecret YAML into a canonical form, so that the same content always results
+// in the same bytes regardless of whether it came from kubeseal or from our own marshalling.
+// - all mapping keys are sorted, including those of spec.encryptedData
+// - `creationTimestamp: null` is dropped from metadata and spec.template.metadata
+func CanonicalizeSealedSecretYAML(sealedSecretYAML []byte) ([]byte, error) {
+	// round trip through the struct first, to drop unknown fields and normalize values
+	var sealedSecret ssv1alpha1.SealedSecret
+	err := yaml.UnmarshalStrict(sealedSecretYAML, &sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to SealedSecret: %v", err)
+	}
+	sealedSecretJSON, err := json.Marshal(sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling SealedSecret to JSON: %v", err)
+	}
+
+	// then through a generic map, since mapping keys are always marshalled in sorted order
+	var obj map[string]interface{}
+	err = json.Unmarshal(sealedSecretJSON, &obj)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling JSON: %v", err)
+	}
+
+	dropNullCreationTimestamp(obj)
+	if spec, ok := obj["spec"].(map[string]interface{}); ok {
+		if template, ok := spec["template"].(map[string]interface{}); ok {
+			dropNullCreationTimestamp(template)
+		}
+	}
+
+	canonicalYAML, err := yaml.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling SealedSecret to YAML: %v", err)
+	}
+	return canonicalYAML, nil
+}
+
+// metav1.Time is marshalled as null when unset, since omitempty doesn't apply to structs
+func dropNullCreationTimestamp(obj map[string]interface{}) {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	if v, ok := metadata["creationTimestamp"]; ok && v == nil {
+		delete(metadata, "creationTimestamp")
+	}
+}
@@ -0,0 +1,73 @@
+package sealer
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestCanonicalizeSealedSecretYAML(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "canonicalize", "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no test inputs found")
+	}
+
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".golden.yaml") {
+			continue
+		}
+		golden := strings.TrimSuffix(input, ".yaml") + ".golden.yaml"
+
+		inputYAML, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		canonicalYAML, err := CanonicalizeSealedSecretYAML(inputYAML)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", input, err)
+			continue
+		}
+
+		if *updateGolden {
+			if err := os.WriteFile(golden, canonicalYAML, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		goldenYAML, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(canonicalYAML, goldenYAML) {
+			t.Errorf("%v: Output differs from %v\ngot:\n%s\nwant:\n%s", input, golden, canonicalYAML, goldenYAML)
+		}
+
+		// canonical form must be a fixed point
+		again, err := CanonicalizeSealedSecretYAML(canonicalYAML)
+		if err != nil {
+			t.Errorf("%v: Unexpected error on second pass: %v", input, err)
+			continue
+		}
+		if !bytes.Equal(again, canonicalYAML) {
+			t.Errorf("%v: Output is not stable\nfirst:\n%s\nsecond:\n%s", input, canonicalYAML, again)
+		}
+	}
+}
+
+func TestGetKeyDiffIsSorted(t *testing.T) {
+	a := map[string]string{"e": "", "d": "", "c": "", "b": "", "a": "", "x": ""}
+	b := map[string]string{"x": ""}
+	for i := 0; i < 10; i++ {
+		keys := GetKeyDiff(a, b)
+		if strings.Join(keys, ",") != "a,b,c,d,e" {
+			t.Fatalf("Expected sorted keys, got %v", keys)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package sealer
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// CertificateInfo is a summary of a sealing certificate
+type CertificateInfo struct {
+	Subject string
+	Issuer  string
+	// hex encoded SHA-256 of the DER encoded certificate, same as `openssl x509 -fingerprint -sha256` without colons
+	Fingerprint string
+	NotBefore   time.Time
+	NotAfter    time.Time
+	// in bits
+	KeySize int
+}
+
+// summarize the first certificate of the PEM encoded chain
+func ParseCertificateInfo(certPEM []byte) (*CertificateInfo, error) {
+	certs, err := parseCertificateChain(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	cert := certs[0]
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	info := &CertificateInfo{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+	}
+	switch publicKey := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		info.KeySize = publicKey.N.BitLen()
+	case *ecdsa.PublicKey:
+		info.KeySize = publicKey.Params().BitSize
+	}
+	return info, nil
+}
+
+// returns whether the certificate is expired, or expires within the given duration from now
+func (info *CertificateInfo) ExpiresWithin(d time.Duration, now time.Time) bool {
+	return !now.Add(d).Before(info.NotAfter)
+}
+
+// validity period of the certificate, which is --key-ttl for the ones generated by genkey or the controller
+func (info *CertificateInfo) ValidFor() time.Duration {
+	return info.NotAfter.Sub(info.NotBefore)
+}
+
+func (info *CertificateInfo) String() string {
+	return fmt.Sprintf("%s (SHA256 %s)", info.Subject, info.Fingerprint)
+}
+
+// read a PEM encoded certificate from a file or an http(s) URL, the same as `kubeseal --cert` accepts,
+// or fetch it from the controller of the target if source is empty
+func ReadCertificate(source string, target Target) ([]byte, error) {
+	if source == "" {
+		return FetchCert(target)
+	}
+
+	if isURL(source) {
+		client := &http.Client{Timeout: 30 * time.Second}
+		resp, err := client.Get(source)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching certificate: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("error fetching certificate: %s: %s", source, resp.Status)
+		}
+		// certificates are a few KB at most
+		certPEM, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return nil, fmt.Errorf("error fetching certificate: %v", err)
+		}
+		return certPEM, nil
+	}
+
+	certPEM, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("error reading certificate: %v", err)
+	}
+	return certPEM, nil
+}
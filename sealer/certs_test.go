@@ -0,0 +1,52 @@
+package sealer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadCertificate(t *testing.T) {
+	c := newTestCertificate(t, "sealed-secret", nil, time.Now().Add(24*time.Hour), nil)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/cert.pem" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(c.certPEM)
+	}))
+	defer server.Close()
+
+	filename := filepath.Join(t.TempDir(), "cert.pem")
+	if err := os.WriteFile(filename, c.certPEM, 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, source := range []string{filename, server.URL + "/v1/cert.pem"} {
+		certPEM, err := ReadCertificate(source, Target{})
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", source, err)
+			continue
+		}
+		info, err := ParseCertificateInfo(certPEM)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", source, err)
+			continue
+		}
+		if info.Subject != "CN=sealed-secret" {
+			t.Errorf("%v: unexpected subject: %q", source, info.Subject)
+		}
+		// newTestCertificate backdates NotBefore by an hour
+		if info.ValidFor().Round(time.Minute) != 25*time.Hour {
+			t.Errorf("%v: unexpected validity period: %v", source, info.ValidFor())
+		}
+	}
+
+	if _, err := ReadCertificate(server.URL+"/nonexistent", Target{}); err == nil {
+		t.Errorf("Expected error for 404, got none")
+	}
+}
@@ -0,0 +1,201 @@
+package sealer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// file name of the cluster aliases in configuration directories
+const ClustersFileName = "clusters.yaml"
+
+// Target tells kubeseal which cluster to seal for. the zero value means the current
+// kubectl context with kubeseal defaults, which is what commands do without --for.
+type Target struct {
+	// kubectl context to talk to the controller with
+	Context string
+	// namespace and name of the controller
+	ControllerNamespace string
+	ControllerName      string
+	// certificate file or URL to seal with offline, instead of fetching it from the controller
+	Cert string
+}
+
+func (t Target) kubesealArgs() []string {
+	args := []string{}
+	if t.Context != "" {
+		args = append(args, "--context", t.Context)
+	}
+	if t.ControllerNamespace != "" {
+		args = append(args, "--controller-namespace", t.ControllerNamespace)
+	}
+	if t.ControllerName != "" {
+		args = append(args, "--controller-name", t.ControllerName)
+	}
+	if t.Cert != "" {
+		args = append(args, "--cert", t.Cert)
+	}
+	return args
+}
+
+func (t Target) kubectlArgs() []string {
+	if t.Context == "" {
+		return []string{}
+	}
+	return []string{"--context", t.Context}
+}
+
+// ClustersConfig defines aliases of clusters which a Secret can be sealed for at once
+// with `seal --for` and `edit --for`, e.g.
+//
+//   clusters:
+//     prod-eu:
+//       context: prod-eu
+//       controllerNamespace: sealed-secrets
+//       cert: certs/prod-eu.pem
+//       output: overlays/prod-eu/{{ .Name }}.sealedsecret.yaml
+//
+// relative paths are relative to the directory containing .sealer/, or to the directory
+// of the file if it's not in a .sealer/ directory.
+type ClustersConfig struct {
+	Clusters map[string]*Cluster `json:"clusters"`
+}
+
+// Cluster is a single cluster of ClustersConfig. all fields are optional.
+type Cluster struct {
+	// alias given by the key in ClustersConfig
+	Alias string `json:"-"`
+
+	Context             string `json:"context,omitempty"`
+	ControllerNamespace string `json:"controllerNamespace,omitempty"`
+	ControllerName      string `json:"controllerName,omitempty"`
+	Cert                string `json:"cert,omitempty"`
+	// Go template of the path to write the SealedSecret to, given .Cluster, .Name and .Namespace
+	Output string `json:"output,omitempty"`
+}
+
+// values given to Cluster.Output
+type OutputPathValues struct {
+	Cluster   string
+	Name      string
+	Namespace string
+}
+
+// read cluster aliases from the given file. if filename is empty, clusters.yaml is looked up
+// in ConfigDirs(), and nil is returned if there is none.
+func LoadClusters(filename string) (*ClustersConfig, error) {
+	if filename == "" {
+		filename = FindConfigFile(ClustersFileName)
+		if filename == "" {
+			return nil, nil
+		}
+	}
+
+	clustersYAML, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading clusters: %v", err)
+	}
+	var config ClustersConfig
+	err = yaml.UnmarshalStrict(clustersYAML, &config)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling clusters: %s: %v", filename, err)
+	}
+
+	baseDir, err := filepath.Abs(filepath.Dir(filename))
+	if err != nil {
+		return nil, fmt.Errorf("error reading clusters: %v", err)
+	}
+	if filepath.Base(baseDir) == repoConfigDirName {
+		baseDir = filepath.Dir(baseDir)
+	}
+
+	for alias, cluster := range config.Clusters {
+		if cluster == nil {
+			cluster = &Cluster{}
+			config.Clusters[alias] = cluster
+		}
+		cluster.Alias = alias
+		if cluster.Cert != "" && !isURL(cluster.Cert) && !filepath.IsAbs(cluster.Cert) {
+			cluster.Cert = filepath.Join(baseDir, cluster.Cert)
+		}
+		if cluster.Output != "" {
+			// catch mistakes early, instead of after sealing for some of the clusters
+			if _, err := parseOutputPath(cluster); err != nil {
+				return nil, fmt.Errorf("error in clusters: %s: %s: %v", filename, alias, err)
+			}
+			if !filepath.IsAbs(cluster.Output) {
+				cluster.Output = filepath.Join(baseDir, cluster.Output)
+			}
+		}
+	}
+	return &config, nil
+}
+
+// returns clusters of the given aliases, in the given order
+func (c *ClustersConfig) Lookup(aliases []string) ([]*Cluster, error) {
+	clusters := []*Cluster{}
+	seen := map[string]bool{}
+	for _, alias := range aliases {
+		cluster, ok := c.Clusters[alias]
+		if !ok {
+			return nil, fmt.Errorf("unknown cluster: %q, must be one of %s", alias, strings.Join(c.aliases(), ", "))
+		}
+		if seen[alias] {
+			continue
+		}
+		seen[alias] = true
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+func (c *ClustersConfig) aliases() []string {
+	aliases := map[string]string{}
+	for alias := range c.Clusters {
+		aliases[alias] = ""
+	}
+	return SortedKeys(aliases)
+}
+
+func (c *Cluster) Target() Target {
+	return Target{
+		Context:             c.Context,
+		ControllerNamespace: c.ControllerNamespace,
+		ControllerName:      c.ControllerName,
+		Cert:                c.Cert,
+	}
+}
+
+// returns where the SealedSecret of the given name and namespace is written to for this cluster
+func (c *Cluster) OutputPath(name string, namespace string) (string, error) {
+	if c.Output == "" {
+		return "", fmt.Errorf("cluster %s has no output path", c.Alias)
+	}
+	tmpl, err := parseOutputPath(c)
+	if err != nil {
+		return "", fmt.Errorf("cluster %s: %v", c.Alias, err)
+	}
+	var path bytes.Buffer
+	err = tmpl.Execute(&path, OutputPathValues{Cluster: c.Alias, Name: name, Namespace: namespace})
+	if err != nil {
+		return "", fmt.Errorf("cluster %s: error rendering output path: %v", c.Alias, err)
+	}
+	return filepath.Clean(path.String()), nil
+}
+
+func parseOutputPath(c *Cluster) (*template.Template, error) {
+	tmpl, err := template.New(c.Alias).Option("missingkey=error").Parse(c.Output)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing output path: %v", err)
+	}
+	return tmpl, nil
+}
+
+func isURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
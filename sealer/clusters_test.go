@@ -0,0 +1,118 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadClusters(t *testing.T) {
+	repoDir := t.TempDir()
+	configDir := filepath.Join(repoDir, repoConfigDirName)
+	if err := os.Mkdir(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	clustersYAML := `
+clusters:
+  prod-eu:
+    context: prod-eu
+    controllerNamespace: sealed-secrets
+    cert: certs/prod-eu.pem
+    output: overlays/{{ .Cluster }}/{{ .Namespace }}-{{ .Name }}.yaml
+  prod-us:
+    cert: https://example.com/prod-us.pem
+    output: /abs/{{ .Name }}.yaml
+  staging:
+`
+	clustersFile := filepath.Join(configDir, ClustersFileName)
+	if err := os.WriteFile(clustersFile, []byte(clustersYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config, err := LoadClusters(clustersFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusters, err := config.Lookup([]string{"prod-us", "prod-eu", "staging", "prod-us"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliases := []string{}
+	for _, cluster := range clusters {
+		aliases = append(aliases, cluster.Alias)
+	}
+	if !reflect.DeepEqual(aliases, []string{"prod-us", "prod-eu", "staging"}) {
+		t.Errorf("unexpected clusters: %v", aliases)
+	}
+
+	expectedTarget := Target{Context: "prod-eu", ControllerNamespace: "sealed-secrets", Cert: filepath.Join(repoDir, "certs/prod-eu.pem")}
+	if target := config.Clusters["prod-eu"].Target(); target != expectedTarget {
+		t.Errorf("unexpected target: %+v", target)
+	}
+	if cert := config.Clusters["prod-us"].Cert; cert != "https://example.com/prod-us.pem" {
+		t.Errorf("URL must be kept as-is, got %s", cert)
+	}
+
+	tests := map[string]struct {
+		cluster  string
+		expected string
+		err      bool
+	}{
+		"relative":  {"prod-eu", filepath.Join(repoDir, "overlays/prod-eu/app-foo.yaml"), false},
+		"absolute":  {"prod-us", "/abs/foo.yaml", false},
+		"no output": {"staging", "", true},
+	}
+	for name, test := range tests {
+		path, err := config.Clusters[test.cluster].OutputPath("foo", "app")
+		if test.err {
+			if err == nil {
+				t.Errorf("%v: expected error, got %s", name, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if path != test.expected {
+			t.Errorf("%v: expected %s, got %s", name, test.expected, path)
+		}
+	}
+
+	if _, err := config.Lookup([]string{"prod-ap"}); err == nil {
+		t.Errorf("expected error for unknown cluster")
+	}
+}
+
+func TestLoadClustersInvalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":  "clusters:\n  prod:\n    contxt: prod\n",
+		"invalid output": "clusters:\n  prod:\n    output: '{{ .Name'\n",
+		"unknown value":  "clusters:\n  prod:\n    output: '{{ .Team }}'\n",
+	}
+	for name, clustersYAML := range tests {
+		clustersFile := filepath.Join(t.TempDir(), ClustersFileName)
+		if err := os.WriteFile(clustersFile, []byte(clustersYAML), 0600); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadClusters(clustersFile)
+		if err == nil {
+			_, err = config.Clusters["prod"].OutputPath("foo", "app")
+		}
+		if err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestTargetKubesealArgs(t *testing.T) {
+	if args := (Target{}).kubesealArgs(); len(args) != 0 {
+		t.Errorf("expected no args for zero Target, got %v", args)
+	}
+	args := Target{Context: "prod", ControllerNamespace: "ss", ControllerName: "ctrl", Cert: "cert.pem"}.kubesealArgs()
+	expected := []string{"--context", "prod", "--controller-namespace", "ss", "--controller-name", "ctrl", "--cert", "cert.pem"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("expected %v, got %v", expected, args)
+	}
+}
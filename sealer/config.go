@@ -0,0 +1,50 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// name of the per-repository configuration directory, looked up from the current directory upwards
+const repoConfigDirName = ".sealer"
+
+// returns directories to look up configuration files in, in order of precedence:
+// the nearest `.sealer` directory from the current directory upwards, then
+// the user configuration directory, e.g. ~/.config/kubectl-sealer
+func ConfigDirs() []string {
+	dirs := []string{}
+
+	if cwd, err := os.Getwd(); err == nil {
+		for dir := cwd; ; dir = filepath.Dir(dir) {
+			candidate := filepath.Join(dir, repoConfigDirName)
+			if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+				dirs = append(dirs, candidate)
+				break
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+
+	if userConfigDir, err := os.UserConfigDir(); err == nil {
+		candidate := filepath.Join(userConfigDir, "kubectl-sealer")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			dirs = append(dirs, candidate)
+		}
+	}
+
+	return dirs
+}
+
+// returns path to the first existing file with the given name in ConfigDirs(),
+// or empty string if there is none
+func FindConfigFile(name string) string {
+	for _, dir := range ConfigDirs() {
+		candidate := filepath.Join(dir, name)
+		if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() {
+			return candidate
+		}
+	}
+	return ""
+}
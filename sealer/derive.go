@@ -0,0 +1,112 @@
+package sealer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// prefix of annotations kubectl-sealer understands
+const AnnotationPrefix = "kubectl-sealer.shusugmt.github.io/"
+
+// annotation declaring keys computed from other keys of the same Secret. the value is a YAML map
+// from derived key to Go template, which is given the other keys of stringData as `.`, e.g.
+//
+//   metadata:
+//     annotations:
+//       kubectl-sealer.shusugmt.github.io/derive: |
+//         DATABASE_URL: 'postgres://{{ .username }}:{{ queryEscape .password }}@{{ .host }}/app'
+//
+// since derived values only change when their inputs do, partial update re-encrypts them only then.
+const DeriveAnnotation = AnnotationPrefix + "derive"
+
+// DeriveFuncs are available in derivation templates, on top of the text/template builtins.
+// unlike GeneratorFuncs, these are all deterministic.
+var DeriveFuncs = template.FuncMap{
+	"b64enc":      func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+	"pathEscape":  url.PathEscape,
+	"queryEscape": url.QueryEscape,
+}
+
+// compute derived keys declared by DeriveAnnotation, overwriting whatever is in them.
+// returns the input as-is if nothing changes, so that it can be compared to detect changes.
+func ApplyDerivations(secretYAML []byte) ([]byte, error) {
+	var secret corev1.Secret
+	err := yaml.UnmarshalStrict(secretYAML, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+
+	derivations, err := parseDerivations(secret.Annotations)
+	if err != nil {
+		return nil, err
+	}
+	if len(derivations) == 0 {
+		return secretYAML, nil
+	}
+
+	// derived keys are not inputs, so that the result doesn't depend on the order of evaluation
+	inputs := map[string]string{}
+	for k, v := range secret.StringData {
+		if _, derived := derivations[k]; !derived {
+			inputs[k] = v
+		}
+	}
+
+	changed := false
+	for _, key := range SortedKeys(derivations) {
+		tmpl, err := template.New(key).Funcs(DeriveFuncs).Option("missingkey=error").Parse(derivations[key])
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: %s: error parsing template: %v", DeriveAnnotation, key, err)
+		}
+		var derived bytes.Buffer
+		err = tmpl.Execute(&derived, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("annotation %s: %s: error rendering template: %v", DeriveAnnotation, key, err)
+		}
+
+		if secret.StringData == nil {
+			secret.StringData = map[string]string{}
+		}
+		if current, exists := secret.StringData[key]; !exists || current != derived.String() {
+			secret.StringData[key] = derived.String()
+			changed = true
+		}
+	}
+	if !changed {
+		return secretYAML, nil
+	}
+
+	derivedSecretYAML, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
+	}
+	return derivedSecretYAML, nil
+}
+
+// returns keys computed by DeriveAnnotation, which are overwritten by ApplyDerivations
+func DerivedKeys(annotations map[string]string) ([]string, error) {
+	derivations, err := parseDerivations(annotations)
+	if err != nil {
+		return nil, err
+	}
+	return SortedKeys(derivations), nil
+}
+
+func parseDerivations(annotations map[string]string) (map[string]string, error) {
+	derivationsYAML, ok := annotations[DeriveAnnotation]
+	if !ok {
+		return nil, nil
+	}
+	var derivations map[string]string
+	err := yaml.UnmarshalStrict([]byte(derivationsYAML), &derivations)
+	if err != nil {
+		return nil, fmt.Errorf("annotation %s: must be a map from key to template: %v", DeriveAnnotation, err)
+	}
+	return derivations, nil
+}
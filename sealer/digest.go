@@ -0,0 +1,111 @@
+package sealer
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// annotation holding HMAC-SHA256 digests of plaintext values, as a JSON object from key to hex digest.
+// unlike the ciphertext, it only changes when the values do, so that changes can be told without decrypting.
+// it's set on both the SealedSecret and spec.template, so that it ends up on the Secret as well,
+// and dropped by Unseal, since it's recomputed from the values whenever they are sealed.
+const DigestAnnotation = AnnotationPrefix + "digests"
+
+// environment variable and file name in configuration directories to read the HMAC key from.
+// it must be kept secret, since low-entropy values could be guessed from their digests otherwise.
+const (
+	DigestKeyEnv      = "SEALER_DIGEST_KEY"
+	DigestKeyFileName = "digest.key"
+)
+
+// read the HMAC key from $SEALER_DIGEST_KEY, or digest.key in ConfigDirs().
+// returns nil if there is none.
+func LoadDigestKey() ([]byte, error) {
+	if key := os.Getenv(DigestKeyEnv); key != "" {
+		return []byte(key), nil
+	}
+	filename := FindConfigFile(DigestKeyFileName)
+	if filename == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading digest key: %v", err)
+	}
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return nil, fmt.Errorf("error reading digest key: %s is empty", filename)
+	}
+	return []byte(key), nil
+}
+
+// returns HMAC-SHA256 digest of each value of the Secret, in both data and stringData
+func SecretDigests(secret *corev1.Secret, key []byte) map[string]string {
+	digests := map[string]string{}
+	digest := func(value []byte) string {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(value)
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+	for k, v := range secret.Data {
+		digests[k] = digest(v)
+	}
+	// stringData takes precedence, the same as the API server does
+	for k, v := range secret.StringData {
+		digests[k] = digest([]byte(v))
+	}
+	return digests
+}
+
+// whether the SealedSecret has DigestAnnotation, which must be kept up to date once it's there
+func HasDigestAnnotation(sealedSecretYAML []byte) bool {
+	var sealedSecret ssv1alpha1.SealedSecret
+	if err := yaml.Unmarshal(sealedSecretYAML, &sealedSecret); err != nil {
+		return false
+	}
+	_, ok := sealedSecret.Annotations[DigestAnnotation]
+	return ok
+}
+
+// set DigestAnnotation computed from the plain Secret on the SealedSecret and its template
+func AnnotateDigests(sealedSecretYAML []byte, secretYAML []byte, key []byte) ([]byte, error) {
+	var sealedSecret ssv1alpha1.SealedSecret
+	err := yaml.UnmarshalStrict(sealedSecretYAML, &sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to SealedSecret: %v", err)
+	}
+	var secret corev1.Secret
+	err = yaml.UnmarshalStrict(secretYAML, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+
+	// json.Marshal sorts keys, so that the same values always result in the same annotation
+	digestsJSON, err := json.Marshal(SecretDigests(&secret, key))
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling digests: %v", err)
+	}
+	if sealedSecret.Annotations == nil {
+		sealedSecret.Annotations = map[string]string{}
+	}
+	sealedSecret.Annotations[DigestAnnotation] = string(digestsJSON)
+	if sealedSecret.Spec.Template.Annotations == nil {
+		sealedSecret.Spec.Template.Annotations = map[string]string{}
+	}
+	sealedSecret.Spec.Template.Annotations[DigestAnnotation] = string(digestsJSON)
+
+	annotatedYAML, err := yaml.Marshal(sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling SealedSecret to YAML: %v", err)
+	}
+	return CanonicalizeSealedSecretYAML(annotatedYAML)
+}
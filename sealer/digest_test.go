@@ -0,0 +1,94 @@
+package sealer
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testDigestSealedSecret = `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: foo
+  namespace: app
+spec:
+  encryptedData:
+    password: AgBy3i4OJSWK+PiTySYZZA9rO43cGDEq
+    username: AgBy3i4OJSWK+PiTySYZZA9rO43cGDEq
+  template:
+    metadata:
+      name: foo
+      namespace: app
+`
+
+func TestSecretDigests(t *testing.T) {
+	secret := &corev1.Secret{
+		Data:       map[string][]byte{"a": []byte("same"), "b": []byte("old")},
+		StringData: map[string]string{"b": "new", "c": "same"},
+	}
+	digests := SecretDigests(secret, []byte("key"))
+	if len(digests) != 3 {
+		t.Fatalf("expected 3 digests, got %v", digests)
+	}
+	if digests["a"] != digests["c"] {
+		t.Errorf("expected same value to have same digest, got %v", digests)
+	}
+	if digests["b"] != SecretDigests(&corev1.Secret{StringData: map[string]string{"b": "new"}}, []byte("key"))["b"] {
+		t.Errorf("expected stringData to take precedence over data")
+	}
+	if digests["a"] == SecretDigests(secret, []byte("other key"))["a"] {
+		t.Errorf("expected digest to depend on the key")
+	}
+	if len(digests["a"]) != 64 {
+		t.Errorf("expected hex encoded SHA256, got %s", digests["a"])
+	}
+}
+
+func TestAnnotateDigests(t *testing.T) {
+	secretYAML := []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\n  namespace: app\nstringData:\n  username: app\n  password: hunter2\n")
+	annotated, err := AnnotateDigests([]byte(testDigestSealedSecret), secretYAML, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sealedSecret ssv1alpha1.SealedSecret
+	if err := yaml.UnmarshalStrict(annotated, &sealedSecret); err != nil {
+		t.Fatal(err)
+	}
+	var digests map[string]string
+	if err := json.Unmarshal([]byte(sealedSecret.Annotations[DigestAnnotation]), &digests); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(digests) != 2 || digests["username"] == "" || digests["password"] == "" {
+		t.Errorf("unexpected digests: %v", digests)
+	}
+	if sealedSecret.Spec.Template.Annotations[DigestAnnotation] != sealedSecret.Annotations[DigestAnnotation] {
+		t.Errorf("expected digests in template as well, got %v", sealedSecret.Spec.Template.Annotations)
+	}
+	if HasDigestAnnotation([]byte(testDigestSealedSecret)) || !HasDigestAnnotation(annotated) {
+		t.Errorf("expected only annotated SealedSecret to have digests")
+	}
+
+	again, err := AnnotateDigests(annotated, secretYAML, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(annotated) {
+		t.Errorf("expected annotating twice to be stable, got:\n%s\n%s", annotated, again)
+	}
+}
+
+func TestLoadDigestKey(t *testing.T) {
+	os.Setenv(DigestKeyEnv, "from-env")
+	defer os.Unsetenv(DigestKeyEnv)
+	key, err := LoadDigestKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(key) != "from-env" {
+		t.Errorf("expected key from %s, got %q", DigestKeyEnv, key)
+	}
+}
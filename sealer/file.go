@@ -0,0 +1,117 @@
+package sealer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// write data to the named file atomically; the content is written to a sibling temporary
+// file, synced to disk and then renamed over the original, so that the file is never
+// left half-written even if the process crashes or the disk is full.
+// mode of an existing file is preserved as long as it's not looser than perm, so that files holding
+// secrets never end up readable by others. perm is used as-is when creating a new one.
+// if backupSuffix is not empty, the original file is kept as filename+backupSuffix, with the same mode.
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode, backupSuffix string) (err error) {
+	// write through symlinks instead of replacing them
+	if resolved, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = resolved
+	}
+
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+
+	originalInfo, err := os.Stat(filename)
+	if err == nil {
+		if !originalInfo.Mode().IsRegular() {
+			return fmt.Errorf("error writing file: %s: not a regular file", filename)
+		}
+		perm &= originalInfo.Mode().Perm()
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	// temporary file must be in the same directory, since rename doesn't work across filesystems
+	f, err := os.CreateTemp(dir, "."+base+".tmp-")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file: %v", err)
+	}
+	// remove the temporary file unless it has been renamed
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = f.Write(data)
+	if err != nil {
+		return fmt.Errorf("error writing file: %s: %v", filename, err)
+	}
+	err = f.Chmod(perm)
+	if err != nil {
+		return fmt.Errorf("error writing file: %s: %v", filename, err)
+	}
+	err = f.Sync()
+	if err != nil {
+		return fmt.Errorf("error writing file: %s: %v", filename, err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("error writing file: %s: %v", filename, err)
+	}
+
+	if originalInfo != nil && backupSuffix != "" {
+		err = copyFile(filename, filename+backupSuffix, perm)
+		if err != nil {
+			return fmt.Errorf("error creating backup: %v", err)
+		}
+	}
+
+	err = os.Rename(f.Name(), filename)
+	if err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+
+	// sync the directory as well so that the rename itself is durable.
+	// not all platforms support this, so ignore errors
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// copy src to dst, which gets perm even if it already exists
+func copyFile(src string, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	// OpenFile applies perm only when creating the file, and a stale backup may be more permissive
+	err = out.Chmod(perm)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	err = out.Sync()
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,137 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	tests := map[string]struct {
+		original     string
+		originalPerm os.FileMode
+		// 0640 if zero
+		perm         os.FileMode
+		staleBackup  bool
+		backupSuffix string
+		expectedPerm os.FileMode
+		expectBackup bool
+	}{
+		"new file":                      {expectedPerm: 0640},
+		"new file, backup":              {backupSuffix: ".bak", expectedPerm: 0640},
+		"replace":                       {original: "old", originalPerm: 0600, expectedPerm: 0600},
+		"replace, backup":               {original: "old", originalPerm: 0600, backupSuffix: ".bak", expectedPerm: 0600, expectBackup: true},
+		"replace, stale backup":         {original: "old", originalPerm: 0600, staleBackup: true, backupSuffix: ".bak", expectedPerm: 0600, expectBackup: true},
+		"replace, custom backup suffix": {original: "old", originalPerm: 0600, backupSuffix: "~", expectedPerm: 0600, expectBackup: true},
+		"replace looser":                {original: "old", originalPerm: 0644, expectedPerm: 0640},
+		"replace looser, backup":        {original: "old", originalPerm: 0644, backupSuffix: ".bak", expectedPerm: 0640, expectBackup: true},
+		"replace 0644 with private key": {original: "old", originalPerm: 0644, perm: 0600, backupSuffix: ".bak", expectedPerm: 0600, expectBackup: true},
+		"replace stricter":              {original: "old", originalPerm: 0400, perm: 0600, expectedPerm: 0400},
+	}
+
+	for name, test := range tests {
+		dir := t.TempDir()
+		filename := filepath.Join(dir, "secret.yaml")
+		if test.original != "" {
+			if err := os.WriteFile(filename, []byte(test.original), test.originalPerm); err != nil {
+				t.Fatal(err)
+			}
+			// umask may have masked perm
+			if err := os.Chmod(filename, test.originalPerm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if test.staleBackup {
+			if err := os.WriteFile(filename+test.backupSuffix, []byte("stale"), 0666); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(filename+test.backupSuffix, 0666); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		perm := test.perm
+		if perm == 0 {
+			perm = 0640
+		}
+		err := WriteFileAtomic(filename, []byte("new"), perm, test.backupSuffix)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != "new" {
+			t.Errorf("%v: expected new content, got %q", name, content)
+		}
+		assertPerm(t, name, filename, test.expectedPerm)
+
+		backup, err := os.ReadFile(filename + test.backupSuffix)
+		if !test.expectBackup {
+			if test.backupSuffix != "" && !os.IsNotExist(err) {
+				t.Errorf("%v: expected no backup, got: %v", name, err)
+			}
+		} else if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+		} else {
+			if string(backup) != test.original {
+				t.Errorf("%v: expected original content in backup, got %q", name, backup)
+			}
+			assertPerm(t, name, filename+test.backupSuffix, test.expectedPerm)
+		}
+
+		assertNoTempFiles(t, name, dir)
+	}
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "secret.yaml")
+	if err := os.WriteFile(filename, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// backup can't be written over a directory
+	if err := os.Mkdir(filename+".bak", 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WriteFileAtomic(filename, []byte("new"), 0600, ".bak")
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "old" {
+		t.Errorf("expected original to be untouched, got %q", content)
+	}
+	assertNoTempFiles(t, "failure", dir)
+}
+
+func assertPerm(t *testing.T, name string, filename string, expected os.FileMode) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != expected {
+		t.Errorf("%v: %s: expected %v, got %v", name, filepath.Base(filename), expected, info.Mode().Perm())
+	}
+}
+
+func assertNoTempFiles(t *testing.T, name string, dir string) {
+	temps, err := filepath.Glob(filepath.Join(dir, ".*.tmp-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(temps) != 0 {
+		t.Errorf("%v: expected temporary files to be removed, got %v", name, temps)
+	}
+}
@@ -0,0 +1,210 @@
+package sealer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// prefix of stringData values in the edit buffer which are replaced by generated values, e.g.
+//
+//   stringData:
+//     password: "!generate 32 alnum"
+//     api-token: "!generate 32 hex"
+//     client-id: "!generate uuid"
+//
+// values consisting of a single template action like `{{ generate 32 "alnum" | bcrypt }}` are
+// rendered with GeneratorFuncs as well.
+const GenerateMarker = "!generate"
+
+// upper bound of length, to catch typos before reading megabytes from crypto/rand
+const maxGenerateLength = 4096
+
+// charsets for Generate, where length is the number of characters
+var generateCharsets = map[string]string{
+	"alnum":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789",
+	"alpha":  "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+	"lower":  "abcdefghijklmnopqrstuvwxyz",
+	"upper":  "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	"digits": "0123456789",
+	// printable ASCII except space
+	"ascii": "!\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~",
+}
+
+// encodings for Generate, where length is the number of random bytes, same as `openssl rand`
+var generateEncodings = map[string]func([]byte) string{
+	"hex":       hex.EncodeToString,
+	"base64":    base64.StdEncoding.EncodeToString,
+	"base64url": base64.RawURLEncoding.EncodeToString,
+}
+
+// GeneratorFuncs are available in templates and in the edit buffer
+var GeneratorFuncs = template.FuncMap{
+	"generate": Generate,
+	"uuid":     GenerateUUID,
+	"bcrypt":   BcryptHash,
+	"htpasswd": Htpasswd,
+}
+
+// generate a random value using crypto/rand. format is either a charset, i.e. one of
+// alnum, alpha, lower, upper, digits and ascii, an encoding of random bytes, i.e. one of hex, base64 and base64url,
+// or uuid, which ignores length.
+func Generate(length int, format string) (string, error) {
+	if format == "uuid" {
+		return GenerateUUID()
+	}
+	if length <= 0 || length > maxGenerateLength {
+		return "", fmt.Errorf("length must be between 1 and %d: %d", maxGenerateLength, length)
+	}
+
+	if charset, ok := generateCharsets[format]; ok {
+		value := make([]byte, length)
+		max := big.NewInt(int64(len(charset)))
+		for i := range value {
+			// rand.Int is uniform, unlike taking modulo of a random byte
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", fmt.Errorf("error generating random value: %v", err)
+			}
+			value[i] = charset[n.Int64()]
+		}
+		return string(value), nil
+	}
+
+	if encode, ok := generateEncodings[format]; ok {
+		randomBytes := make([]byte, length)
+		if _, err := rand.Read(randomBytes); err != nil {
+			return "", fmt.Errorf("error generating random value: %v", err)
+		}
+		return encode(randomBytes), nil
+	}
+
+	formats := append(SortedKeys(generateCharsets), "hex", "base64", "base64url", "uuid")
+	return "", fmt.Errorf("unknown format: %q, must be one of %v", format, formats)
+}
+
+// generate a random (version 4) UUID
+func GenerateUUID() (string, error) {
+	u := make([]byte, 16)
+	if _, err := rand.Read(u); err != nil {
+		return "", fmt.Errorf("error generating random value: %v", err)
+	}
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16]), nil
+}
+
+// returns bcrypt hash of the value with the default cost
+func BcryptHash(value string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(value), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("error hashing value with bcrypt: %v", err)
+	}
+	return string(hash), nil
+}
+
+// returns a htpasswd line with bcrypt hashed password, which apache and nginx both accept
+func Htpasswd(user string, password string) (string, error) {
+	if user == "" || strings.Contains(user, ":") {
+		return "", fmt.Errorf("invalid htpasswd user: %q", user)
+	}
+	hash, err := BcryptHash(password)
+	if err != nil {
+		return "", err
+	}
+	return user + ":" + hash, nil
+}
+
+// replace generator markers in stringData of the Secret with generated values.
+// returns the input as-is if there is none, so that it can be compared to detect changes.
+func ExpandGenerators(secretYAML []byte) ([]byte, error) {
+	var secret corev1.Secret
+	err := yaml.UnmarshalStrict(secretYAML, &secret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+
+	expanded := false
+	for _, key := range SortedKeys(secret.StringData) {
+		value, ok, err := expandGenerator(secret.StringData[key])
+		if err != nil {
+			return nil, fmt.Errorf("stringData[%s]: %v", key, err)
+		}
+		if ok {
+			secret.StringData[key] = value
+			expanded = true
+		}
+	}
+	if !expanded {
+		return secretYAML, nil
+	}
+
+	expandedSecretYAML, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
+	}
+	return expandedSecretYAML, nil
+}
+
+// returns the generated value and true if the value is a generator marker
+func expandGenerator(value string) (string, bool, error) {
+	trimmed := strings.TrimSpace(value)
+
+	if trimmed == GenerateMarker || strings.HasPrefix(trimmed, GenerateMarker+" ") {
+		args := strings.Fields(strings.TrimPrefix(trimmed, GenerateMarker))
+		switch {
+		case len(args) == 1 && args[0] == "uuid":
+			generated, err := GenerateUUID()
+			return generated, true, err
+		case len(args) == 2:
+			length, err := strconv.Atoi(args[0])
+			if err != nil {
+				return "", false, fmt.Errorf("invalid length: %q", args[0])
+			}
+			generated, err := Generate(length, args[1])
+			return generated, true, err
+		default:
+			return "", false, fmt.Errorf("usage: %s LENGTH FORMAT, or %s uuid", GenerateMarker, GenerateMarker)
+		}
+	}
+
+	// only a single action calling one of GeneratorFuncs is treated as template, so that existing values
+	// which happen to contain `{{`, e.g. config files of other templating tools, are left intact
+	if isGeneratorAction(trimmed) {
+		tmpl, err := template.New("value").Funcs(GeneratorFuncs).Parse(trimmed)
+		if err != nil {
+			return "", false, fmt.Errorf("error parsing template: %v", err)
+		}
+		var generated bytes.Buffer
+		if err := tmpl.Execute(&generated, nil); err != nil {
+			return "", false, fmt.Errorf("error rendering template: %v", err)
+		}
+		return generated.String(), true, nil
+	}
+
+	return value, false, nil
+}
+
+func isGeneratorAction(value string) bool {
+	if !strings.HasPrefix(value, "{{") || !strings.HasSuffix(value, "}}") || strings.Count(value, "{{") != 1 {
+		return false
+	}
+	// strip delimiters, including trim markers like `{{- ... -}}`
+	action := strings.Trim(strings.TrimSuffix(strings.TrimPrefix(value, "{{"), "}}"), " \t-")
+	fields := strings.Fields(action)
+	if len(fields) == 0 {
+		return false
+	}
+	_, ok := GeneratorFuncs[fields[0]]
+	return ok
+}
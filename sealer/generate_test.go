@@ -0,0 +1,124 @@
+package sealer
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := map[string]struct {
+		length  int
+		format  string
+		pattern string
+	}{
+		"alnum":  {length: 32, format: "alnum", pattern: `^[A-Za-z0-9]{32}$`},
+		"alpha":  {length: 8, format: "alpha", pattern: `^[A-Za-z]{8}$`},
+		"lower":  {length: 8, format: "lower", pattern: `^[a-z]{8}$`},
+		"upper":  {length: 8, format: "upper", pattern: `^[A-Z]{8}$`},
+		"digits": {length: 6, format: "digits", pattern: `^[0-9]{6}$`},
+		"ascii":  {length: 64, format: "ascii", pattern: `^[!-~]{64}$`},
+		"hex":    {length: 16, format: "hex", pattern: `^[0-9a-f]{32}$`},
+		"base64": {length: 32, format: "base64", pattern: `^[A-Za-z0-9+/]{43}=$`},
+		"uuid":   {length: 0, format: "uuid", pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+	}
+
+	for name, tc := range tests {
+		value, err := Generate(tc.length, tc.format)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if !regexp.MustCompile(tc.pattern).MatchString(value) {
+			t.Errorf("%v: %q does not match %s", name, value, tc.pattern)
+		}
+	}
+
+	errorCases := map[string]struct {
+		length int
+		format string
+	}{
+		"zero length":    {length: 0, format: "alnum"},
+		"too long":       {length: maxGenerateLength + 1, format: "alnum"},
+		"unknown format": {length: 8, format: "emoji"},
+	}
+	for name, tc := range errorCases {
+		if _, err := Generate(tc.length, tc.format); err == nil {
+			t.Errorf("%v: Expected error, got none", name)
+		}
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	line, err := Htpasswd("admin", "secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parts := strings.SplitN(line, ":", 2)
+	if parts[0] != "admin" {
+		t.Errorf("unexpected user: %q", parts[0])
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(parts[1]), []byte("secret")); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := Htpasswd("ad:min", "secret"); err == nil {
+		t.Errorf("Expected error for user with colon, got none")
+	}
+}
+
+func TestExpandGenerators(t *testing.T) {
+	secretYAML := []byte(`apiVersion: v1
+kind: Secret
+metadata:
+  name: foo
+stringData:
+  password: "!generate 32 alnum"
+  token: "!generate 16 hex"
+  id: "!generate uuid"
+  auth: '{{ htpasswd "admin" (generate 16 "alnum") }}'
+  helm: "{{ .Values.password }}"
+  plain: bar
+`)
+	expandedYAML, err := ExpandGenerators(secretYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var secret corev1.Secret
+	if err := yaml.UnmarshalStrict(expandedYAML, &secret); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !regexp.MustCompile(`^[A-Za-z0-9]{32}$`).MatchString(secret.StringData["password"]) {
+		t.Errorf("password is not generated: %q", secret.StringData["password"])
+	}
+	if token, err := hex.DecodeString(secret.StringData["token"]); err != nil || len(token) != 16 {
+		t.Errorf("token is not generated: %q", secret.StringData["token"])
+	}
+	if len(secret.StringData["id"]) != 36 {
+		t.Errorf("id is not generated: %q", secret.StringData["id"])
+	}
+	if !strings.HasPrefix(secret.StringData["auth"], "admin:$2a$") {
+		t.Errorf("auth is not generated: %q", secret.StringData["auth"])
+	}
+	if secret.StringData["helm"] != "{{ .Values.password }}" || secret.StringData["plain"] != "bar" {
+		t.Errorf("unexpected change to values without generators: %v", secret.StringData)
+	}
+
+	// nothing to expand, the input must be returned as-is so that edit can detect no change
+	plainYAML := []byte("apiVersion: v1\nkind: Secret\nstringData:\n  foo:   bar\n")
+	if expandedYAML, err := ExpandGenerators(plainYAML); err != nil || string(expandedYAML) != string(plainYAML) {
+		t.Errorf("expected input as-is, got %q: %v", expandedYAML, err)
+	}
+
+	for _, invalid := range []string{"!generate", "!generate 32", "!generate x alnum", "!generate 32 emoji"} {
+		invalidYAML := []byte("stringData:\n  foo: \"" + invalid + "\"\n")
+		if _, err := ExpandGenerators(invalidYAML); err == nil {
+			t.Errorf("%v: Expected error, got none", invalid)
+		}
+	}
+}
+
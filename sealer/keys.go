@@ -0,0 +1,142 @@
+package sealer
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"sigs.k8s.io/yaml"
+)
+
+// label the sealed-secrets controller looks up its sealing keys by
+// https://github.com/bitnami-labs/sealed-secrets/blob/v0.16.0/pkg/controller/keys.go
+const (
+	SealingKeyLabel       = "sealedsecrets.bitnami.com/sealed-secrets-key"
+	SealingKeyLabelActive = "active"
+)
+
+// prefix of sealing key Secret names, the same as the controller uses as generateName
+const sealingKeyNamePrefix = "sealed-secrets-key"
+
+// build a Secret holding the sealing key pair, which the controller picks up once applied to its namespace
+func SealingKeySecretYAML(namespace string, certPEM []byte, keyPEM []byte) ([]byte, error) {
+	secret := corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			// generateName doesn't work with `kubectl apply`, so emulate it
+			Name:      sealingKeyNamePrefix + utilrand.String(5),
+			Namespace: namespace,
+			Labels: map[string]string{
+				SealingKeyLabel: SealingKeyLabelActive,
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+
+	secretYAML, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
+	}
+	return secretYAML, nil
+}
+
+// returns all sealing keys in the controller namespace of the target as a List, which kubeseal accepts as --recovery-private-key
+func GetSealingKeysYAML(target Target) ([]byte, error) {
+	kubectlCommandArgs := target.kubectlArgs()
+	kubectlCommandArgs = append(kubectlCommandArgs,
+		"get", "secret",
+		"-l", SealingKeyLabel,
+		"-n", target.ControllerNamespace,
+		"-o", "yaml",
+	)
+	kubectlCommand := exec.Command("kubectl", kubectlCommandArgs...)
+	var stderr bytes.Buffer
+	kubectlCommand.Stderr = &stderr
+	sealingKeysYAML, err := kubectlCommand.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error invoking kubectl as %v: %v: %s", kubectlCommand.Args, err, stderr.Bytes())
+	}
+	return sealingKeysYAML, nil
+}
+
+// SealingKey is a sealing key Secret of the controller, without the private key
+type SealingKey struct {
+	Name              string
+	CreationTimestamp time.Time
+	// value of SealingKeyLabel, e.g. active or compromised
+	Status      string
+	Certificate *CertificateInfo
+}
+
+// parse the output of GetSealingKeysYAML, ordered by creation time; the last one is used for sealing
+func ParseSealingKeys(sealingKeysYAML []byte) ([]SealingKey, error) {
+	var secrets corev1.SecretList
+	err := yaml.Unmarshal(sealingKeysYAML, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to SecretList: %v", err)
+	}
+
+	keys := []SealingKey{}
+	for _, secret := range secrets.Items {
+		info, err := ParseCertificateInfo(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			return nil, fmt.Errorf("sealing key %s: %v", secret.Name, err)
+		}
+		keys = append(keys, SealingKey{
+			Name:              secret.Name,
+			CreationTimestamp: secret.CreationTimestamp.Time,
+			Status:            secret.Labels[SealingKeyLabel],
+			Certificate:       info,
+		})
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].CreationTimestamp.Before(keys[j].CreationTimestamp)
+	})
+	return keys, nil
+}
+
+// fetch the certificate the controller of the target currently seals with
+func FetchCert(target Target) ([]byte, error) {
+	kubesealCommand := exec.Command("kubeseal", append([]string{"--fetch-cert"}, target.kubesealArgs()...)...)
+	var stderr bytes.Buffer
+	kubesealCommand.Stderr = &stderr
+	certPEM, err := kubesealCommand.Output()
+	if err != nil {
+		return nil, fmt.Errorf("error invoking kubeseal as %v: %v: %s", kubesealCommand.Args, err, stderr.Bytes())
+	}
+	return certPEM, nil
+}
+
+// create a sealing key Secret from the key pair in the controller namespace. returns name of the Secret.
+func ImportSealingKey(controllerNamespace string, certPEM []byte, keyPEM []byte) (string, error) {
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		return "", fmt.Errorf("invalid key pair: %v", err)
+	}
+	secretYAML, err := SealingKeySecretYAML(controllerNamespace, certPEM, keyPEM)
+	if err != nil {
+		return "", err
+	}
+
+	// `kubectl apply` would keep the whole Secret, private key included, in last-applied-configuration annotation
+	kubectlCommand := exec.Command("kubectl", "create", "-f", "-", "-o", "name")
+	kubectlCommand.Stdin = bytes.NewReader(secretYAML)
+	output, err := kubectlCommand.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("error invoking kubectl as %v: %v: %s", kubectlCommand.Args, err, output)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,92 @@
+package sealer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestSealingKeySecretYAML(t *testing.T) {
+	c := newTestCertificate(t, "sealed-secret", nil, time.Now().Add(time.Hour), nil)
+	secretYAML, err := SealingKeySecretYAML("kube-system", c.certPEM, c.keyPEM)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var secret corev1.Secret
+	if err := yaml.UnmarshalStrict(secretYAML, &secret); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if secret.Namespace != "kube-system" || secret.Labels[SealingKeyLabel] != SealingKeyLabelActive || secret.Type != corev1.SecretTypeTLS {
+		t.Errorf("unexpected Secret: %s", secretYAML)
+	}
+	if errs := ValidateSecret(&secret); len(errs) > 0 {
+		t.Errorf("Unexpected error: %v", errs.ToAggregate())
+	}
+}
+
+func TestParseSealingKeys(t *testing.T) {
+	older := newTestCertificate(t, "older", nil, time.Now().Add(-time.Minute), nil)
+	newer := newTestCertificate(t, "newer", nil, time.Now().Add(time.Hour), nil)
+
+	// as returned by `kubectl get secret -o yaml`, newer one first to check ordering
+	sealingKeysYAML := []byte(fmt.Sprintf(`apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: sealed-secrets-keyb
+    namespace: kube-system
+    creationTimestamp: "2021-02-01T00:00:00Z"
+    labels:
+      sealedsecrets.bitnami.com/sealed-secrets-key: active
+  type: kubernetes.io/tls
+  data:
+    tls.crt: %s
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: sealed-secrets-keya
+    namespace: kube-system
+    creationTimestamp: "2021-01-01T00:00:00Z"
+    labels:
+      sealedsecrets.bitnami.com/sealed-secrets-key: compromised
+  type: kubernetes.io/tls
+  data:
+    tls.crt: %s
+`, base64.StdEncoding.EncodeToString(newer.certPEM), base64.StdEncoding.EncodeToString(older.certPEM)))
+
+	keys, err := ParseSealingKeys(sealingKeysYAML)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(keys))
+	}
+	if keys[0].Name != "sealed-secrets-keya" || keys[0].Status != "compromised" {
+		t.Errorf("unexpected first key: %+v", keys[0])
+	}
+	if keys[1].Name != "sealed-secrets-keyb" || keys[1].Status != "active" {
+		t.Errorf("unexpected second key: %+v", keys[1])
+	}
+
+	fingerprint := sha256.Sum256(newer.cert.Raw)
+	if keys[1].Certificate.Fingerprint != hex.EncodeToString(fingerprint[:]) {
+		t.Errorf("unexpected fingerprint: %s", keys[1].Certificate.Fingerprint)
+	}
+	if keys[1].Certificate.KeySize != 256 {
+		t.Errorf("unexpected key size: %d", keys[1].Certificate.KeySize)
+	}
+	if !keys[0].Certificate.ExpiresWithin(0, time.Now()) || keys[1].Certificate.ExpiresWithin(0, time.Now()) {
+		t.Errorf("unexpected expiry: %v, %v", keys[0].Certificate.NotAfter, keys[1].Certificate.NotAfter)
+	}
+	if !keys[1].Certificate.ExpiresWithin(2*time.Hour, time.Now()) {
+		t.Errorf("expected to expire within 2 hours: %v", keys[1].Certificate.NotAfter)
+	}
+}
@@ -0,0 +1,381 @@
+package sealer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// apiVersion and kinds of function configs understood by `krm`
+const (
+	KRMAPIVersion             = "kubectl-sealer.shusugmt.github.io/v1alpha1"
+	KindSealedSecretGenerator = "SealedSecretGenerator"
+	KindSealedSecretValidator = "SealedSecretValidator"
+)
+
+// prefixes of annotations kustomize and other orchestrators use to track resources. they are not
+// part of the Secret, so they are moved to the SealedSecret instead of being sealed into its template.
+var krmAnnotationPrefixes = []string{
+	"config.kubernetes.io/",
+	"internal.config.kubernetes.io/",
+	"kustomize.config.k8s.io/",
+}
+
+// ResourceList is the input and output of KRM functions.
+// items are kept as generic objects, so that resources other than Secrets pass through untouched.
+// https://github.com/kubernetes-sigs/kustomize/blob/master/cmd/config/docs/api-conventions/functions-spec.md
+type ResourceList struct {
+	APIVersion     string                   `json:"apiVersion"`
+	Kind           string                   `json:"kind"`
+	Items          []map[string]interface{} `json:"items"`
+	FunctionConfig map[string]interface{}   `json:"functionConfig,omitempty"`
+	Results        []KRMResult              `json:"results,omitempty"`
+}
+
+// KRMResult is a single result of a KRM function, reported by the orchestrator
+type KRMResult struct {
+	Message     string          `json:"message"`
+	Severity    string          `json:"severity"`
+	ResourceRef *KRMResourceRef `json:"resourceRef,omitempty"`
+	File        *KRMFile        `json:"file,omitempty"`
+}
+
+type KRMResourceRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace,omitempty"`
+}
+
+type KRMFile struct {
+	Path string `json:"path"`
+}
+
+// SealedSecretGenerator is the function config of the sealing function, e.g.
+//
+//   apiVersion: kubectl-sealer.shusugmt.github.io/v1alpha1
+//   kind: SealedSecretGenerator
+//   metadata:
+//     name: seal
+//     annotations:
+//       config.kubernetes.io/function: |
+//         exec:
+//           path: ./sealer-krm.sh
+//   cert: certs/prod.pem
+//   namespace: app
+//   secrets:
+//   - name: app
+//     literals: [username=app]
+//     files: [tls.key=secrets/tls.key]
+//     envs: [secrets/app.env]
+//
+// all plain Secrets in the items are sealed as well. Secrets without namespace are put in the
+// namespace given here, since sealing binds them to it.
+type SealedSecretGenerator struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// certificate file or URL to seal with; required, since the function runs without cluster access
+	Cert string `json:"cert"`
+	// namespace of the Secrets without one; required for them unless they are sealed cluster-wide
+	Namespace string `json:"namespace,omitempty"`
+	// Secrets to generate, like secretGenerator of kustomization.yaml
+	Secrets []SecretGeneratorArgs `json:"secrets,omitempty"`
+}
+
+// SecretGeneratorArgs generates a Secret, like secretGenerator of kustomization.yaml.
+// paths are relative to the current directory, which is the kustomization directory when run by kustomize.
+type SecretGeneratorArgs struct {
+	Name        string            `json:"name"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Type        corev1.SecretType `json:"type,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// KEY=VALUE
+	Literals []string `json:"literals,omitempty"`
+	// PATH, or KEY=PATH
+	Files []string `json:"files,omitempty"`
+	// files with a KEY=VALUE line each
+	Envs []string `json:"envs,omitempty"`
+}
+
+func ReadResourceList(r io.Reader) (*ResourceList, error) {
+	resourceListYAML, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading ResourceList: %v", err)
+	}
+	var list ResourceList
+	err = yaml.Unmarshal(resourceListYAML, &list)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to ResourceList: %v", err)
+	}
+	if list.Kind != "ResourceList" {
+		return nil, fmt.Errorf("expected ResourceList, got %q", list.Kind)
+	}
+	return &list, nil
+}
+
+// returns kind of the function config, which tells what the function should do
+func (list *ResourceList) FunctionKind() string {
+	kind, _ := list.FunctionConfig["kind"].(string)
+	return kind
+}
+
+func (list *ResourceList) SealedSecretGenerator() (*SealedSecretGenerator, error) {
+	functionConfigYAML, err := yaml.Marshal(list.FunctionConfig)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling function config: %v", err)
+	}
+	var generator SealedSecretGenerator
+	err = yaml.UnmarshalStrict(functionConfigYAML, &generator)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling function config to %s: %v", KindSealedSecretGenerator, err)
+	}
+	if generator.Cert == "" {
+		return nil, fmt.Errorf("%s: cert is required", KindSealedSecretGenerator)
+	}
+	return &generator, nil
+}
+
+// replace plain Secrets in the items with SealedSecrets, and append SealedSecrets of the generated Secrets.
+// seal is given the Secret YAML, and returns the SealedSecret YAML.
+func (list *ResourceList) SealSecrets(generator *SealedSecretGenerator, seal func([]byte) ([]byte, error)) error {
+	for i, item := range list.Items {
+		if !isPlainSecret(item) {
+			continue
+		}
+		secretYAML, err := yaml.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("error marshalling Secret: %v", err)
+		}
+		var secret corev1.Secret
+		err = yaml.UnmarshalStrict(secretYAML, &secret)
+		if err != nil {
+			return fmt.Errorf("%s: error unmarshalling yaml to kubernetes Secret: %v", krmItemName(item), err)
+		}
+		if secret.Namespace == "" {
+			secret.Namespace = generator.Namespace
+		}
+		sealedSecret, err := sealKRMSecret(&secret, seal)
+		if err != nil {
+			return fmt.Errorf("%s: %v", krmItemName(item), err)
+		}
+		list.Items[i] = sealedSecret
+	}
+
+	for _, args := range generator.Secrets {
+		secret, err := args.Secret()
+		if err != nil {
+			return err
+		}
+		if secret.Namespace == "" {
+			secret.Namespace = generator.Namespace
+		}
+		sealedSecret, err := sealKRMSecret(secret, seal)
+		if err != nil {
+			return fmt.Errorf("Secret/%s: %v", args.Name, err)
+		}
+		list.Items = append(list.Items, sealedSecret)
+	}
+	return nil
+}
+
+// add an error result for every plain Secret in the items. returns the number of them.
+func (list *ResourceList) ValidateNoPlainSecrets() int {
+	found := 0
+	for _, item := range list.Items {
+		if !isPlainSecret(item) {
+			continue
+		}
+		found++
+		metadata, _ := item["metadata"].(map[string]interface{})
+		name, _ := metadata["name"].(string)
+		namespace, _ := metadata["namespace"].(string)
+		result := KRMResult{
+			Message:     "plain Secret must be sealed before it's committed; use SealedSecret instead",
+			Severity:    LintSeverityError,
+			ResourceRef: &KRMResourceRef{APIVersion: "v1", Kind: "Secret", Name: name, Namespace: namespace},
+		}
+		if path := krmItemPath(item); path != "" {
+			result.File = &KRMFile{Path: path}
+		}
+		list.Results = append(list.Results, result)
+	}
+	return found
+}
+
+// build the Secret to generate
+func (args *SecretGeneratorArgs) Secret() (*corev1.Secret, error) {
+	if args.Name == "" {
+		return nil, fmt.Errorf("%s: name of secrets is required", KindSealedSecretGenerator)
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        args.Name,
+			Namespace:   args.Namespace,
+			Labels:      args.Labels,
+			Annotations: args.Annotations,
+		},
+		Type: args.Type,
+	}
+	if secret.Type == "" {
+		secret.Type = corev1.SecretTypeOpaque
+	}
+
+	add := func(source string, key string, value []byte) error {
+		if _, exists := secret.StringData[key]; exists {
+			return fmt.Errorf("Secret/%s: %s: duplicate key %q", args.Name, source, key)
+		}
+		if _, exists := secret.Data[key]; exists {
+			return fmt.Errorf("Secret/%s: %s: duplicate key %q", args.Name, source, key)
+		}
+		// keep text readable in the sealed template, but binary files can only go to data
+		if utf8.Valid(value) {
+			if secret.StringData == nil {
+				secret.StringData = map[string]string{}
+			}
+			secret.StringData[key] = string(value)
+		} else {
+			if secret.Data == nil {
+				secret.Data = map[string][]byte{}
+			}
+			secret.Data[key] = value
+		}
+		return nil
+	}
+
+	for _, literal := range args.Literals {
+		parts := strings.SplitN(literal, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("Secret/%s: invalid literal %q, must be KEY=VALUE", args.Name, literal)
+		}
+		if err := add("literals", parts[0], []byte(parts[1])); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range args.Files {
+		key, path := filepath.Base(file), file
+		if parts := strings.SplitN(file, "=", 2); len(parts) == 2 {
+			key, path = parts[0], parts[1]
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Secret/%s: %v", args.Name, err)
+		}
+		if err := add("files", key, content); err != nil {
+			return nil, err
+		}
+	}
+	for _, env := range args.Envs {
+		content, err := os.ReadFile(env)
+		if err != nil {
+			return nil, fmt.Errorf("Secret/%s: %v", args.Name, err)
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(content))
+		line := 0
+		for scanner.Scan() {
+			line++
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			parts := strings.SplitN(text, "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, fmt.Errorf("Secret/%s: %s:%d: invalid line, must be KEY=VALUE", args.Name, env, line)
+			}
+			if err := add(env, parts[0], []byte(parts[1])); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return secret, nil
+}
+
+// seal the Secret, keeping orchestrator annotations on the SealedSecret itself
+func sealKRMSecret(secret *corev1.Secret, seal func([]byte) ([]byte, error)) (map[string]interface{}, error) {
+	// kubeseal would fall back to the namespace of the kubeconfig context, which has nothing to do
+	// with where the SealedSecret is deployed, and the controller would fail to decrypt it
+	if secret.Namespace == "" && ssv1alpha1.SecretScope(secret) != ssv1alpha1.ClusterWideScope {
+		return nil, fmt.Errorf("namespace is required unless sealed cluster-wide; set it on the Secret or the %s", KindSealedSecretGenerator)
+	}
+
+	krmAnnotations := map[string]string{}
+	for k, v := range secret.Annotations {
+		for _, prefix := range krmAnnotationPrefixes {
+			if strings.HasPrefix(k, prefix) {
+				krmAnnotations[k] = v
+				delete(secret.Annotations, k)
+			}
+		}
+	}
+	if len(secret.Annotations) == 0 {
+		secret.Annotations = nil
+	}
+
+	secretYAML, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
+	}
+	sealedSecretYAML, err := seal(secretYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	var sealedSecret map[string]interface{}
+	err = yaml.Unmarshal(sealedSecretYAML, &sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to SealedSecret: %v", err)
+	}
+	if len(krmAnnotations) > 0 {
+		metadata, _ := sealedSecret["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			sealedSecret["metadata"] = metadata
+		}
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		if annotations == nil {
+			annotations = map[string]interface{}{}
+			metadata["annotations"] = annotations
+		}
+		for k, v := range krmAnnotations {
+			annotations[k] = v
+		}
+	}
+	return sealedSecret, nil
+}
+
+func isPlainSecret(item map[string]interface{}) bool {
+	apiVersion, _ := item["apiVersion"].(string)
+	kind, _ := item["kind"].(string)
+	return apiVersion == "v1" && kind == "Secret"
+}
+
+func krmItemName(item map[string]interface{}) string {
+	kind, _ := item["kind"].(string)
+	metadata, _ := item["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return kind + "/" + name
+}
+
+// returns the file the item came from, if the orchestrator told
+func krmItemPath(item map[string]interface{}) string {
+	metadata, _ := item["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	for _, key := range []string{"internal.config.kubernetes.io/path", "config.kubernetes.io/path"} {
+		if path, ok := annotations[key].(string); ok {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,217 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const testResourceList = `
+apiVersion: config.kubernetes.io/v1
+kind: ResourceList
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: config
+  data:
+    foo: bar
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: plain
+    namespace: app
+    annotations:
+      config.kubernetes.io/index: "0"
+      internal.config.kubernetes.io/path: secret.yaml
+      team: a
+  stringData:
+    password: hunter2
+functionConfig:
+  apiVersion: kubectl-sealer.shusugmt.github.io/v1alpha1
+  kind: SealedSecretGenerator
+  metadata:
+    name: seal
+  cert: cert.pem
+  secrets:
+  - name: generated
+    namespace: app
+    literals: [username=app]
+`
+
+// pretends to seal, keeping what was given in the template
+func fakeSealKRM(secretYAML []byte) ([]byte, error) {
+	var secret corev1.Secret
+	if err := yaml.UnmarshalStrict(secretYAML, &secret); err != nil {
+		return nil, err
+	}
+	sealedSecret := map[string]interface{}{
+		"apiVersion": "bitnami.com/v1alpha1",
+		"kind":       "SealedSecret",
+		"metadata":   map[string]interface{}{"name": secret.Name, "namespace": secret.Namespace},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"metadata": secret.ObjectMeta},
+		},
+	}
+	return yaml.Marshal(sealedSecret)
+}
+
+func TestResourceListSealSecrets(t *testing.T) {
+	list, err := ReadResourceList(strings.NewReader(testResourceList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kind := list.FunctionKind(); kind != KindSealedSecretGenerator {
+		t.Fatalf("unexpected function kind: %s", kind)
+	}
+	generator, err := list.SealedSecretGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = list.SealSecrets(generator, fakeSealKRM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list.Items) != 3 {
+		t.Fatalf("expected 3 items, got %d", len(list.Items))
+	}
+	if kind := list.Items[0]["kind"]; kind != "ConfigMap" {
+		t.Errorf("expected ConfigMap to pass through, got %v", kind)
+	}
+	for _, item := range list.Items[1:] {
+		if kind := item["kind"]; kind != "SealedSecret" {
+			t.Errorf("expected SealedSecret, got %v", kind)
+		}
+	}
+	if n := list.ValidateNoPlainSecrets(); n != 0 {
+		t.Errorf("expected no plain Secrets left, got %d", n)
+	}
+
+	sealedYAML, err := yaml.Marshal(list.Items[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := string(sealedYAML)
+	if !strings.Contains(sealed, "internal.config.kubernetes.io/path: secret.yaml") {
+		t.Errorf("expected orchestrator annotations on SealedSecret, got:\n%s", sealed)
+	}
+	if strings.Count(sealed, "config.kubernetes.io/index") != 1 || strings.Count(sealed, "team: a") != 1 {
+		t.Errorf("expected orchestrator annotations only on SealedSecret, and the others only in template, got:\n%s", sealed)
+	}
+}
+
+func TestResourceListValidateNoPlainSecrets(t *testing.T) {
+	list, err := ReadResourceList(strings.NewReader(testResourceList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := list.ValidateNoPlainSecrets(); n != 1 {
+		t.Fatalf("expected 1 plain Secret, got %d", n)
+	}
+	result := list.Results[0]
+	if result.Severity != LintSeverityError || result.ResourceRef.Name != "plain" || result.File == nil || result.File.Path != "secret.yaml" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestSecretGeneratorArgs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tls.key"), []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bin"), []byte{0xff, 0xfe}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "app.env"), []byte("# comment\n\nDB_USER=app\nDB_PASS=a=b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	args := SecretGeneratorArgs{
+		Name:     "app",
+		Literals: []string{"username=app"},
+		Files:    []string{filepath.Join(dir, "tls.key"), "blob=" + filepath.Join(dir, "bin")},
+		Envs:     []string{filepath.Join(dir, "app.env")},
+	}
+	secret, err := args.Secret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"username": "app", "tls.key": "key", "DB_USER": "app", "DB_PASS": "a=b"}
+	for k, v := range expected {
+		if secret.StringData[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, secret.StringData[k])
+		}
+	}
+	if string(secret.Data["blob"]) != "\xff\xfe" {
+		t.Errorf("expected binary file in data, got %q", secret.Data["blob"])
+	}
+	if secret.Type != corev1.SecretTypeOpaque {
+		t.Errorf("expected Opaque, got %s", secret.Type)
+	}
+
+	tests := map[string]SecretGeneratorArgs{
+		"no name":         {Literals: []string{"a=b"}},
+		"invalid literal": {Name: "app", Literals: []string{"a"}},
+		"duplicate key":   {Name: "app", Literals: []string{"username=a"}, Envs: []string{filepath.Join(dir, "app.env")}, Files: []string{"username=" + filepath.Join(dir, "tls.key")}},
+		"missing file":    {Name: "app", Files: []string{filepath.Join(dir, "nonexistent")}},
+	}
+	for name, args := range tests {
+		if _, err := args.Secret(); err == nil {
+			t.Errorf("%v: expected error", name)
+		}
+	}
+}
+
+func TestResourceListSealSecretsNamespace(t *testing.T) {
+	plainSecret := func(namespace string, annotations map[string]interface{}) map[string]interface{} {
+		metadata := map[string]interface{}{"name": "plain", "annotations": annotations}
+		if namespace != "" {
+			metadata["namespace"] = namespace
+		}
+		return map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "metadata": metadata, "stringData": map[string]interface{}{"password": "hunter2"}}
+	}
+	clusterWide := map[string]interface{}{"sealedsecrets.bitnami.com/cluster-wide": "true"}
+
+	tests := map[string]struct {
+		generator         SealedSecretGenerator
+		item              map[string]interface{}
+		expectedNamespace string
+		expectedError     bool
+	}{
+		"namespace of Secret":               {generator: SealedSecretGenerator{Namespace: "default"}, item: plainSecret("app", nil), expectedNamespace: "app"},
+		"namespace of generator":            {generator: SealedSecretGenerator{Namespace: "default"}, item: plainSecret("", nil), expectedNamespace: "default"},
+		"no namespace":                      {item: plainSecret("", nil), expectedError: true},
+		"no namespace, cluster-wide":        {item: plainSecret("", clusterWide), expectedNamespace: ""},
+		"generated, namespace of args":      {generator: SealedSecretGenerator{Namespace: "default", Secrets: []SecretGeneratorArgs{{Name: "generated", Namespace: "app", Literals: []string{"a=b"}}}}, expectedNamespace: "app"},
+		"generated, namespace of generator": {generator: SealedSecretGenerator{Namespace: "default", Secrets: []SecretGeneratorArgs{{Name: "generated", Literals: []string{"a=b"}}}}, expectedNamespace: "default"},
+		"generated, no namespace":           {generator: SealedSecretGenerator{Secrets: []SecretGeneratorArgs{{Name: "generated", Literals: []string{"a=b"}}}}, expectedError: true},
+	}
+
+	for name, test := range tests {
+		list := &ResourceList{APIVersion: "config.kubernetes.io/v1", Kind: "ResourceList"}
+		if test.item != nil {
+			list.Items = append(list.Items, test.item)
+		}
+		err := list.SealSecrets(&test.generator, fakeSealKRM)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("%v: expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		metadata, _ := list.Items[0]["metadata"].(map[string]interface{})
+		namespace, _ := metadata["namespace"].(string)
+		if namespace != test.expectedNamespace {
+			t.Errorf("%v: expected namespace %q, got %q", name, test.expectedNamespace, namespace)
+		}
+	}
+}
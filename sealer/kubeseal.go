@@ -5,46 +5,42 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/yaml"
 )
 
-func Unseal(sealedSecretYAML []byte, sealedSecretsControllerNamespace string) (secretYAML []byte, err error) {
-	// create and open a temporary file
-	f, err := os.CreateTemp("", "kubectl-sealer-")
-	if err != nil {
-		return nil, fmt.Errorf("error creating temporary file: %v", err)
-	}
-
-	// close and remove the temporary file at the end of the program
-	defer f.Close()
-	defer os.Remove(f.Name())
-
-	// get sealing keys
-	kubectlCommandArgs := []string{
-		"get", "secret",
-		"-l", "sealedsecrets.bitnami.com/sealed-secrets-key",
-		"-n", sealedSecretsControllerNamespace,
-		"-o", "yaml",
-	}
-	kubectlCommand := exec.Command("kubectl", kubectlCommandArgs...)
-	sealingKeysYAML, err := kubectlCommand.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("error invoking kubectl as %v: %v: %s", kubectlCommand.Args, err, sealingKeysYAML)
-	}
+// UnsealOptions tells Unseal where to get the sealing private keys from
+type UnsealOptions struct {
+	// cluster to get the keys from, unless PrivateKeyFiles is given; ControllerNamespace is required
+	Target Target
+	// local private keys instead of the ones in the cluster; PEM files, Secrets, or backups by `keys backup`.
+	// any of them may be passphrase encrypted.
+	PrivateKeyFiles []string
+	// asked only if there is an encrypted key file, at most once
+	Passphrase func() (string, error)
+}
 
-	// store sealing keys into temporary file
-	_, err = f.Write(sealingKeysYAML)
+func Unseal(sealedSecretYAML []byte, opts UnsealOptions) (secretYAML []byte, err error) {
+	keyFiles, err := sealingKeyFiles(opts)
+	// shred and remove temporary files at the end of the program, even if some of them failed
+	defer func() {
+		for _, t := range keyFiles.temporary {
+			t.Cleanup()
+		}
+	}()
 	if err != nil {
-		return nil, fmt.Errorf("error writing sealing keys to temporary file: %v", err)
+		return nil, err
 	}
 
 	// unseal
 	kubesealCommandArgs := []string{
 		"--recovery-unseal",
-		"--recovery-private-key", f.Name(),
+	}
+	for _, name := range keyFiles.names {
+		kubesealCommandArgs = append(kubesealCommandArgs, "--recovery-private-key", name)
 	}
 	kubesealCommand := exec.Command("kubeseal", kubesealCommandArgs...)
 	kubesealCommandStdin, _ := kubesealCommand.StdinPipe()
@@ -75,6 +71,12 @@ func Unseal(sealedSecretYAML []byte, sealedSecretsControllerNamespace string) (s
 	// delete metadata.ownerReference
 	secret.ObjectMeta.OwnerReferences = nil
 
+	// digests are recomputed from the values when sealing, so editing them makes no sense
+	delete(secret.Annotations, DigestAnnotation)
+	if len(secret.Annotations) == 0 {
+		secret.Annotations = nil
+	}
+
 	// generate YAML from struct
 	secretYAML, err = yaml.Marshal(secret)
 	if err != nil {
@@ -84,10 +86,11 @@ func Unseal(sealedSecretYAML []byte, sealedSecretsControllerNamespace string) (s
 	return secretYAML, nil
 }
 
-func Seal(secretYAML []byte, allowEmptyData bool) (sealedSecretYAML []byte, err error) {
+func Seal(secretYAML []byte, allowEmptyData bool, target Target) (sealedSecretYAML []byte, err error) {
 	kubesealCommandArgs := []string{
 		"-o", "yaml",
 	}
+	kubesealCommandArgs = append(kubesealCommandArgs, target.kubesealArgs()...)
 	if allowEmptyData {
 		kubesealCommandArgs = append(kubesealCommandArgs, "--allow-empty-data")
 	}
@@ -102,14 +105,16 @@ func Seal(secretYAML []byte, allowEmptyData bool) (sealedSecretYAML []byte, err
 	if err != nil {
 		return nil, fmt.Errorf("error invoking kubeseal as %v: %v: %s", kubesealCommand.Args, err, sealedSecretYAML)
 	}
-	return sealedSecretYAML, nil
+	// kubeseal output layout may differ from ours, so make it byte-stable
+	return CanonicalizeSealedSecretYAML(sealedSecretYAML)
 }
 
-func EncryptRaw(value []byte, secret corev1.Secret) (encryptedValue []byte, err error) {
+func EncryptRaw(value []byte, secret corev1.Secret, target Target) (encryptedValue []byte, err error) {
 	kubesealCommandArgs := []string{
 		"--raw",
 		"--from-file", "/dev/stdin",
 	}
+	kubesealCommandArgs = append(kubesealCommandArgs, target.kubesealArgs()...)
 	scope := ssv1alpha1.SecretScope(&secret)
 	switch scope {
 	case ssv1alpha1.StrictScope:
@@ -135,3 +140,71 @@ func EncryptRaw(value []byte, secret corev1.Secret) (encryptedValue []byte, err
 	}
 	return encryptedValue, nil
 }
+
+type keyFiles struct {
+	names     []string
+	temporary []*privateTempFile
+}
+
+// prepare files kubeseal can read the sealing keys from. keys from the cluster and decrypted keys
+// are put into private temporary files, which the caller must clean up.
+func sealingKeyFiles(opts UnsealOptions) (keyFiles, error) {
+	files := keyFiles{}
+
+	if len(opts.PrivateKeyFiles) == 0 {
+		t, err := createPrivateTempFile("sealing-keys.yaml")
+		if err != nil {
+			return files, err
+		}
+		files.temporary = append(files.temporary, t)
+
+		sealingKeysYAML, err := GetSealingKeysYAML(opts.Target)
+		if err != nil {
+			return files, err
+		}
+		err = t.Write(sealingKeysYAML)
+		if err != nil {
+			return files, fmt.Errorf("error writing sealing keys to temporary file: %v", err)
+		}
+		files.names = append(files.names, t.Name)
+		return files, nil
+	}
+
+	passphrase := ""
+	for _, filename := range opts.PrivateKeyFiles {
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			return files, fmt.Errorf("error reading private key: %v", err)
+		}
+		if !IsPassphraseEncrypted(content) {
+			files.names = append(files.names, filename)
+			continue
+		}
+
+		if passphrase == "" {
+			if opts.Passphrase == nil {
+				return files, fmt.Errorf("private key %s is encrypted, but no passphrase is available", filename)
+			}
+			passphrase, err = opts.Passphrase()
+			if err != nil {
+				return files, err
+			}
+		}
+		decrypted, err := DecryptWithPassphrase(content, passphrase)
+		if err != nil {
+			return files, fmt.Errorf("private key %s: %v", filename, err)
+		}
+
+		t, err := createPrivateTempFile(filepath.Base(filename))
+		if err != nil {
+			return files, err
+		}
+		files.temporary = append(files.temporary, t)
+		err = t.Write(decrypted)
+		if err != nil {
+			return files, fmt.Errorf("error writing decrypted private key to temporary file: %v", err)
+		}
+		files.names = append(files.names, t.Name)
+	}
+	return files, nil
+}
@@ -0,0 +1,255 @@
+package sealer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// severities of lint findings
+const (
+	LintSeverityError   = "error"
+	LintSeverityWarning = "warning"
+)
+
+// LintRules describes each rule reported in LintFinding.Rule
+var LintRules = map[string]string{
+	"parse":             "document must be a well-formed SealedSecret",
+	"yaml":              "document should be well-formed YAML; reported as a warning, since it may not be a SealedSecret",
+	"template-metadata": "spec.template.metadata must agree with metadata of the SealedSecret",
+	"scope":             "scope annotations must be consistent and have the name/namespace the scope requires",
+	"encrypted-data":    "spec.encryptedData values must be base64 encoded ciphertexts produced by kubeseal",
+	"key-name":          "keys of spec.encryptedData must be valid Secret data keys",
+	"required-keys":     "keys required by the Secret type must be present",
+}
+
+// LintFinding is a single problem found by LintSealedSecretYAML
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	// field path within the document, e.g. spec.encryptedData[password]
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// YAMLDocument is a single document of a multi-document YAML file
+type YAMLDocument struct {
+	Content []byte
+	// 1-based line number where the document starts in the file
+	Line int
+	// comments and blank lines before Content, e.g. `# Source:` of helm
+	Preamble []byte
+}
+
+// split multi-document YAML by `---` separator lines, keeping track of line numbers.
+// documents consisting only of comments or whitespace are dropped.
+func SplitYAMLDocuments(data []byte) []YAMLDocument {
+	docs := []YAMLDocument{}
+	var current, preamble bytes.Buffer
+	start := 1
+
+	flush := func() {
+		if !isEmptyYAMLDocument(current.Bytes()) {
+			docs = append(docs, YAMLDocument{
+				Content:  append([]byte{}, current.Bytes()...),
+				Line:     start,
+				Preamble: append([]byte{}, preamble.Bytes()...),
+			})
+		}
+		current.Reset()
+		preamble.Reset()
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), len(data)+1)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Bytes()
+		if bytes.Equal(bytes.TrimRight(text, " \t\r"), []byte("---")) || bytes.HasPrefix(text, []byte("--- ")) {
+			flush()
+			start = line + 1
+			continue
+		}
+		if current.Len() == 0 && isEmptyYAMLDocument(text) {
+			// point at the first meaningful line
+			start = line + 1
+			preamble.Write(text)
+			preamble.WriteByte('\n')
+			continue
+		}
+		current.Write(text)
+		current.WriteByte('\n')
+	}
+	flush()
+	return docs
+}
+
+func isEmptyYAMLDocument(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) > 0 && trimmed[0] != '#' {
+			return false
+		}
+	}
+	return true
+}
+
+// returns kind of the YAML document, without validating it any further
+func DocumentKind(doc []byte) (string, error) {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+		return "", fmt.Errorf("error unmarshalling yaml: %v", err)
+	}
+	return typeMeta.Kind, nil
+}
+
+// check a SealedSecret structurally, without decrypting it
+func LintSealedSecretYAML(sealedSecretYAML []byte) []LintFinding {
+	findings := []LintFinding{}
+	report := func(rule string, severity string, fldPath *field.Path, format string, args ...interface{}) {
+		f := LintFinding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)}
+		if fldPath != nil {
+			f.Field = fldPath.String()
+		}
+		findings = append(findings, f)
+	}
+
+	var sealedSecret ssv1alpha1.SealedSecret
+	if err := yaml.UnmarshalStrict(sealedSecretYAML, &sealedSecret); err != nil {
+		report("parse", LintSeverityError, nil, "error unmarshalling yaml to SealedSecret: %v", err)
+		return findings
+	}
+	if sealedSecret.Kind != "SealedSecret" {
+		report("parse", LintSeverityError, field.NewPath("kind"), "expected SealedSecret, got %q", sealedSecret.Kind)
+		return findings
+	}
+
+	// template metadata
+	templatePath := field.NewPath("spec", "template", "metadata")
+	template := sealedSecret.Spec.Template
+	if template.Name != "" && template.Name != sealedSecret.Name {
+		report("template-metadata", LintSeverityError, templatePath.Child("name"), "%q differs from metadata.name %q", template.Name, sealedSecret.Name)
+	}
+	if template.Namespace != "" && template.Namespace != sealedSecret.Namespace {
+		report("template-metadata", LintSeverityError, templatePath.Child("namespace"), "%q differs from metadata.namespace %q", template.Namespace, sealedSecret.Namespace)
+	}
+
+	// scope
+	scope := ssv1alpha1.SecretScope(&sealedSecret)
+	if len(template.Annotations) > 0 {
+		templateScope := ssv1alpha1.SecretScope(&template)
+		if templateScope != scope {
+			report("scope", LintSeverityError, templatePath.Child("annotations"), "scope %s differs from %s of metadata.annotations", templateScope.String(), scope.String())
+		}
+	}
+	if scope == ssv1alpha1.StrictScope && sealedSecret.Name == "" {
+		report("scope", LintSeverityError, field.NewPath("metadata", "name"), "strict scope requires a name, since it's bound to the ciphertext")
+	}
+	// namespace may be given at apply time, e.g. by kustomize, so this is only a warning
+	if (scope == ssv1alpha1.StrictScope || scope == ssv1alpha1.NamespaceWideScope) && sealedSecret.Namespace == "" {
+		report("scope", LintSeverityWarning, field.NewPath("metadata", "namespace"), "%s scope is bound to a namespace, but none is specified", scope.String())
+	}
+
+	// encrypted data
+	dataPath := field.NewPath("spec", "encryptedData")
+	if len(sealedSecret.Spec.Data) > 0 {
+		report("encrypted-data", LintSeverityWarning, field.NewPath("spec", "data"), "spec.data is deprecated, re-seal to use spec.encryptedData")
+	}
+	if len(sealedSecret.Spec.EncryptedData) == 0 && len(sealedSecret.Spec.Data) == 0 {
+		report("encrypted-data", LintSeverityWarning, dataPath, "no encrypted data")
+	}
+	for _, key := range SortedKeys(sealedSecret.Spec.EncryptedData) {
+		for _, msg := range validation.IsConfigMapKey(key) {
+			report("key-name", LintSeverityError, dataPath.Key(key), "%s", msg)
+		}
+		if msg := checkCiphertext(sealedSecret.Spec.EncryptedData[key]); msg != "" {
+			report("encrypted-data", LintSeverityError, dataPath.Key(key), "%s", msg)
+		}
+	}
+
+	// keys required by type
+	for _, key := range missingRequiredKeys(template.Type, sealedSecret.Spec.EncryptedData) {
+		report("required-keys", LintSeverityError, dataPath.Key(key), "required for type %s", template.Type)
+	}
+	if template.Type == corev1.SecretTypeServiceAccountToken && template.Annotations[corev1.ServiceAccountNameKey] == "" {
+		report("required-keys", LintSeverityError, templatePath.Child("annotations").Key(corev1.ServiceAccountNameKey), "required for type %s", template.Type)
+	}
+
+	return findings
+}
+
+// ciphertext produced by sealed-secrets hybrid encryption consists of
+// 2 bytes length of RSA-OAEP encrypted session key, the encrypted session key itself,
+// and AES-GCM encrypted value with 16 bytes of authentication tag.
+// https://github.com/bitnami-labs/sealed-secrets/blob/v0.16.0/pkg/crypto/crypto.go
+const (
+	minRSACiphertextSize = 2048 / 8
+	gcmTagSize           = 16
+)
+
+func checkCiphertext(value string) string {
+	ciphertext, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return fmt.Sprintf("not valid base64: %v", err)
+	}
+	if len(ciphertext) < 2+minRSACiphertextSize+gcmTagSize {
+		return fmt.Sprintf("too short to be a ciphertext: %d bytes", len(ciphertext))
+	}
+	rsaLen := int(binary.BigEndian.Uint16(ciphertext))
+	if rsaLen < minRSACiphertextSize || 2+rsaLen+gcmTagSize > len(ciphertext) {
+		return fmt.Sprintf("malformed ciphertext: invalid session key length %d", rsaLen)
+	}
+	return ""
+}
+
+func missingRequiredKeys(secretType corev1.SecretType, encryptedData map[string]string) []string {
+	has := func(key string) bool {
+		_, exists := encryptedData[key]
+		return exists
+	}
+	missing := []string{}
+	switch secretType {
+	case corev1.SecretTypeDockercfg:
+		if !has(corev1.DockerConfigKey) {
+			missing = append(missing, corev1.DockerConfigKey)
+		}
+	case corev1.SecretTypeDockerConfigJson:
+		if !has(corev1.DockerConfigJsonKey) {
+			missing = append(missing, corev1.DockerConfigJsonKey)
+		}
+	case corev1.SecretTypeBasicAuth:
+		// either of them is enough, same as ValidateSecret
+		if !has(corev1.BasicAuthUsernameKey) && !has(corev1.BasicAuthPasswordKey) {
+			missing = append(missing, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+		}
+	case corev1.SecretTypeSSHAuth:
+		if !has(corev1.SSHAuthPrivateKey) {
+			missing = append(missing, corev1.SSHAuthPrivateKey)
+		}
+	case corev1.SecretTypeTLS:
+		if !has(corev1.TLSCertKey) {
+			missing = append(missing, corev1.TLSCertKey)
+		}
+		if !has(corev1.TLSPrivateKeyKey) {
+			missing = append(missing, corev1.TLSPrivateKeyKey)
+		}
+	case corev1.SecretTypeBootstrapToken:
+		if !has(bootstrapTokenIDKey) {
+			missing = append(missing, bootstrapTokenIDKey)
+		}
+		if !has(bootstrapTokenSecretKey) {
+			missing = append(missing, bootstrapTokenSecretKey)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,127 @@
+package sealer
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/bitnami-labs/sealed-secrets/pkg/crypto"
+)
+
+func TestLintSealedSecretYAML(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ciphertext, err := crypto.HybridEncrypt(rand.Reader, &key.PublicKey, []byte("value"), []byte("default/mysecret"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	valid := base64.StdEncoding.EncodeToString(ciphertext)
+
+	sealedSecretYAML := func(metadata string, template string, secretType string, encryptedData string) []byte {
+		return []byte(fmt.Sprintf(`apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+%s
+spec:
+  encryptedData:
+%s
+  template:
+    metadata:
+%s
+    type: %s
+`, metadata, encryptedData, template, secretType))
+	}
+	const (
+		metadata = "  name: mysecret\n  namespace: default"
+		template = "      name: mysecret\n      namespace: default"
+	)
+
+	tests := map[string]struct {
+		yaml  []byte
+		rules []string
+	}{
+		"valid": {
+			yaml:  sealedSecretYAML(metadata, template, "Opaque", "    password: "+valid),
+			rules: []string{},
+		},
+		"valid tls": {
+			yaml:  sealedSecretYAML(metadata, template, "kubernetes.io/tls", "    tls.crt: "+valid+"\n    tls.key: "+valid),
+			rules: []string{},
+		},
+		"broken yaml": {
+			yaml:  []byte("kind: SealedSecret\nspec: ["),
+			rules: []string{"parse"},
+		},
+		"name mismatch": {
+			yaml:  sealedSecretYAML(metadata, "      name: other\n      namespace: default", "Opaque", "    password: "+valid),
+			rules: []string{"template-metadata"},
+		},
+		"scope mismatch": {
+			yaml: sealedSecretYAML(metadata+"\n  annotations:\n    sealedsecrets.bitnami.com/cluster-wide: \"true\"",
+				template+"\n      annotations:\n        sealedsecrets.bitnami.com/namespace-wide: \"true\"", "Opaque", "    password: "+valid),
+			rules: []string{"scope"},
+		},
+		"strict without namespace": {
+			yaml:  sealedSecretYAML("  name: mysecret", "      name: mysecret", "Opaque", "    password: "+valid),
+			rules: []string{"scope"},
+		},
+		"bad base64": {
+			yaml:  sealedSecretYAML(metadata, template, "Opaque", "    password: not-base64!"),
+			rules: []string{"encrypted-data"},
+		},
+		"too short": {
+			yaml:  sealedSecretYAML(metadata, template, "Opaque", "    password: "+base64.StdEncoding.EncodeToString([]byte("plaintext"))),
+			rules: []string{"encrypted-data"},
+		},
+		"invalid key name": {
+			yaml:  sealedSecretYAML(metadata, template, "Opaque", "    \"pass word\": "+valid),
+			rules: []string{"key-name"},
+		},
+		"tls missing key": {
+			yaml:  sealedSecretYAML(metadata, template, "kubernetes.io/tls", "    tls.crt: "+valid),
+			rules: []string{"required-keys"},
+		},
+	}
+
+	for name, tc := range tests {
+		findings := LintSealedSecretYAML(tc.yaml)
+		rules := []string{}
+		for _, f := range findings {
+			rules = append(rules, f.Rule)
+		}
+		if fmt.Sprint(rules) != fmt.Sprint(tc.rules) {
+			t.Errorf("%v: expected rules %v, got %v: %+v", name, tc.rules, rules, findings)
+		}
+	}
+}
+
+func TestSplitYAMLDocuments(t *testing.T) {
+	input := []byte(`# leading comment
+---
+kind: Secret
+---
+# only a comment
+---
+
+kind: SealedSecret
+metadata:
+  name: foo
+`)
+	docs := SplitYAMLDocuments(input)
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d: %q", len(docs), docs)
+	}
+	if docs[0].Line != 3 || string(docs[0].Content) != "kind: Secret\n" {
+		t.Errorf("unexpected first document: line %d: %q", docs[0].Line, docs[0].Content)
+	}
+	if docs[1].Line != 8 || string(docs[1].Content) != "kind: SealedSecret\nmetadata:\n  name: foo\n" {
+		t.Errorf("unexpected second document: line %d: %q", docs[1].Line, docs[1].Content)
+	}
+	if len(docs[0].Preamble) != 0 || string(docs[1].Preamble) != "\n" {
+		t.Errorf("unexpected preambles: %q %q", docs[0].Preamble, docs[1].Preamble)
+	}
+}
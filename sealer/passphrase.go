@@ -0,0 +1,198 @@
+package sealer
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// environment variable to take the passphrase from, for non-interactive use
+const PassphraseEnv = "SEALER_KEY_PASSPHRASE"
+
+// PEM block type of data encrypted by EncryptWithPassphrase
+const PassphraseEncryptedBlockType = "KUBECTL-SEALER ENCRYPTED DATA"
+
+// scrypt parameters recommended for interactive logins as of 2017
+// https://pkg.go.dev/golang.org/x/crypto/scrypt
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltSize     = 16
+)
+
+// encrypt data with a key derived from the passphrase by scrypt, using AES-256-GCM.
+// the result is a PEM block carrying KDF parameters in its headers, so that it can be
+// stored and recognized as text.
+func EncryptWithPassphrase(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating salt: %v", err)
+	}
+	gcm, err := passphraseCipher(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("error generating nonce: %v", err)
+	}
+
+	block := &pem.Block{
+		Type: PassphraseEncryptedBlockType,
+		Headers: map[string]string{
+			"KDF":    "scrypt",
+			"N":      strconv.Itoa(scryptN),
+			"R":      strconv.Itoa(scryptR),
+			"P":      strconv.Itoa(scryptP),
+			"Salt":   base64.StdEncoding.EncodeToString(salt),
+			"Cipher": "AES-256-GCM",
+		},
+		Bytes: gcm.Seal(nonce, nonce, plaintext, nil),
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// returns whether the data looks like what EncryptWithPassphrase produces
+func IsPassphraseEncrypted(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && block.Type == PassphraseEncryptedBlockType
+}
+
+// decrypt data produced by EncryptWithPassphrase
+func DecryptWithPassphrase(data []byte, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != PassphraseEncryptedBlockType {
+		return nil, fmt.Errorf("not a passphrase encrypted PEM block")
+	}
+	if block.Headers["KDF"] != "scrypt" || block.Headers["Cipher"] != "AES-256-GCM" {
+		return nil, fmt.Errorf("unsupported encryption: KDF %q, cipher %q", block.Headers["KDF"], block.Headers["Cipher"])
+	}
+
+	params := map[string]int{}
+	for _, name := range []string{"N", "R", "P"} {
+		value, err := strconv.Atoi(block.Headers[name])
+		if err != nil || value <= 0 {
+			return nil, fmt.Errorf("invalid scrypt parameter %s: %q", name, block.Headers[name])
+		}
+		params[name] = value
+	}
+	// don't let a crafted file make us allocate gigabytes
+	if params["N"] > 1<<20 || params["R"]*params["P"] > 64 {
+		return nil, fmt.Errorf("scrypt parameters are too large: N=%d, R=%d, P=%d", params["N"], params["R"], params["P"])
+	}
+	salt, err := base64.StdEncoding.DecodeString(block.Headers["Salt"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %v", err)
+	}
+
+	gcm, err := passphraseCipher(passphrase, salt, params["N"], params["R"], params["P"])
+	if err != nil {
+		return nil, err
+	}
+	if len(block.Bytes) < gcm.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is too short")
+	}
+	nonce, ciphertext := block.Bytes[:gcm.NonceSize()], block.Bytes[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		// GCM can't tell a wrong passphrase apart from tampered data
+		return nil, fmt.Errorf("error decrypting: wrong passphrase or corrupted data")
+	}
+	return plaintext, nil
+}
+
+func passphraseCipher(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, scryptKeyLen)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving key from passphrase: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var (
+	passphraseFDMu sync.Mutex
+	// first line read from each fd; a fd can be read only once, but the passphrase may be asked
+	// again, e.g. for each cluster. empty if reading failed.
+	passphrasesFromFD = map[int]string{}
+)
+
+// get the passphrase from $SEALER_KEY_PASSPHRASE, the first line read from fd if it's not negative,
+// or the terminal. if confirm is true, the terminal prompt is repeated to catch typos, since
+// a mistyped passphrase for new encrypted data makes it useless.
+func ReadPassphrase(fd int, confirm bool) (string, error) {
+	if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+		if passphrase == "" {
+			return "", fmt.Errorf("$%s is empty", PassphraseEnv)
+		}
+		return passphrase, nil
+	}
+
+	if fd >= 0 {
+		return readPassphraseFD(fd)
+	}
+
+	passphrase, err := PromptSecret("passphrase")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+	if confirm {
+		confirmation, err := PromptSecret("passphrase (again)")
+		if err != nil {
+			return "", err
+		}
+		if passphrase != confirmation {
+			return "", fmt.Errorf("passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// read the first line from fd, and close it. the fd is closed explicitly right away, since the
+// finalizer of *os.File would close it at an arbitrary time, and another file may have the number by then.
+func readPassphraseFD(fd int) (string, error) {
+	passphraseFDMu.Lock()
+	defer passphraseFDMu.Unlock()
+
+	if passphrase, read := passphrasesFromFD[fd]; read {
+		if passphrase == "" {
+			return "", fmt.Errorf("error reading passphrase from file descriptor %d: already closed", fd)
+		}
+		return passphrase, nil
+	}
+
+	f := os.NewFile(uintptr(fd), "passphrase-fd")
+	if f == nil {
+		return "", fmt.Errorf("invalid file descriptor: %d", fd)
+	}
+	line, err := bufio.NewReader(f).ReadString('\n')
+	f.Close()
+	passphrase := strings.TrimRight(line, "\r\n")
+	passphrasesFromFD[fd] = passphrase
+	if passphrase == "" {
+		return "", fmt.Errorf("error reading passphrase from file descriptor %d: %v", fd, err)
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,92 @@
+package sealer
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	plaintext := []byte("apiVersion: v1\nkind: List\nitems: []\n")
+	encrypted, err := EncryptWithPassphrase(plaintext, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !IsPassphraseEncrypted(encrypted) {
+		t.Errorf("expected to be recognized as encrypted: %s", encrypted)
+	}
+	if bytes.Contains(encrypted, plaintext) {
+		t.Errorf("plaintext leaked: %s", encrypted)
+	}
+
+	decrypted, err := DecryptWithPassphrase(encrypted, "correct horse battery staple")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("expected %q, got %q", plaintext, decrypted)
+	}
+
+	if _, err := DecryptWithPassphrase(encrypted, "wrong"); err == nil {
+		t.Errorf("Expected error for wrong passphrase, got none")
+	}
+	if _, err := DecryptWithPassphrase(plaintext, "correct horse battery staple"); err == nil {
+		t.Errorf("Expected error for unencrypted data, got none")
+	}
+	if _, err := EncryptWithPassphrase(plaintext, ""); err == nil {
+		t.Errorf("Expected error for empty passphrase, got none")
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	os.Setenv(PassphraseEnv, "from env")
+	defer os.Unsetenv(PassphraseEnv)
+
+	passphrase, err := ReadPassphrase(-1, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if passphrase != "from env" {
+		t.Errorf("expected passphrase from env, got %q", passphrase)
+	}
+
+	os.Unsetenv(PassphraseEnv)
+	// fd numbers are reused, so forget what previous tests read
+	passphrasesFromFD = map[int]string{}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w.WriteString("from fd\nsecond line\n")
+	w.Close()
+	fd := int(r.Fd())
+	// asked again, e.g. for each cluster, the same passphrase is returned without reading the fd
+	for i := 0; i < 2; i++ {
+		passphrase, err = ReadPassphrase(fd, true)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if passphrase != "from fd" {
+			t.Errorf("expected passphrase from fd, got %q", passphrase)
+		}
+	}
+	// the fd has been closed explicitly, instead of being left to a finalizer
+	if err := r.Close(); err == nil {
+		t.Errorf("expected fd to be closed already")
+	}
+
+	passphrasesFromFD = map[int]string{}
+	r, w, err = os.Pipe()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	w.Close()
+	fd = int(r.Fd())
+	for i := 0; i < 2; i++ {
+		if _, err := ReadPassphrase(fd, false); err == nil {
+			t.Errorf("expected error for empty fd")
+		}
+	}
+	// closed already, but this keeps the finalizer of r from closing the number later
+	r.Close()
+}
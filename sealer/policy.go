@@ -0,0 +1,149 @@
+package sealer
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+)
+
+// file name of the policy in configuration directories
+const PolicyFileName = "policy.yaml"
+
+// Policy is a set of organization specific rules that Secrets must satisfy before being sealed,
+// on top of what ValidateSecret checks. e.g.
+//
+//   rules:
+//   - name: ownership
+//     requiredLabels: [team, app]
+//   - name: production
+//     namespaces: ["prod-*"]
+//     allowedScopes: [strict]
+//     forbiddenKeys: ["*password*"]
+//     maxValueSize: 4096
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule is a single rule of Policy. all constraints are optional.
+type PolicyRule struct {
+	// name is shown in violations
+	Name string `json:"name"`
+	// glob patterns of namespaces this rule applies to; applies to all namespaces if empty
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// labels which must exist with non-empty value
+	RequiredLabels []string `json:"requiredLabels,omitempty"`
+	// glob patterns of data keys which must not be used
+	ForbiddenKeys []string `json:"forbiddenKeys,omitempty"`
+	// maximum size of each value in bytes
+	MaxValueSize int `json:"maxValueSize,omitempty"`
+	// glob patterns of namespaces Secrets may be created in
+	AllowedNamespaces []string `json:"allowedNamespaces,omitempty"`
+	// allowed sealing scopes; one of strict, namespace-wide and cluster-wide
+	AllowedScopes []string `json:"allowedScopes,omitempty"`
+}
+
+// read policy from the given file. if filename is empty, policy.yaml is looked up in
+// ConfigDirs(), and nil is returned if there is none; nil Policy accepts everything.
+func LoadPolicy(filename string) (*Policy, error) {
+	if filename == "" {
+		filename = FindConfigFile(PolicyFileName)
+		if filename == "" {
+			return nil, nil
+		}
+	}
+
+	policyYAML, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy: %v", err)
+	}
+	var policy Policy
+	err = yaml.UnmarshalStrict(policyYAML, &policy)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling policy: %s: %v", filename, err)
+	}
+
+	// catch mistakes in the policy itself early, instead of silently matching nothing
+	for i, rule := range policy.Rules {
+		patterns := append(append(append([]string{}, rule.Namespaces...), rule.ForbiddenKeys...), rule.AllowedNamespaces...)
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("error in policy: %s: rules[%d]: invalid pattern %q: %v", filename, i, pattern, err)
+			}
+		}
+		for _, scopeString := range rule.AllowedScopes {
+			var scope ssv1alpha1.SealingScope
+			if err := scope.Set(scopeString); err != nil {
+				return nil, fmt.Errorf("error in policy: %s: rules[%d]: invalid scope %q: %v", filename, i, scopeString, err)
+			}
+		}
+	}
+
+	return &policy, nil
+}
+
+// check the Secret against all applicable rules. data is expected to be filled, like ValidateSecret.
+func (p *Policy) Validate(secret *corev1.Secret) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if p == nil {
+		return allErrs
+	}
+	for i, rule := range p.Rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rules[%d]", i)
+		}
+		if len(rule.Namespaces) > 0 && !matchAny(rule.Namespaces, secret.Namespace) {
+			continue
+		}
+		allErrs = append(allErrs, rule.validate(name, secret)...)
+	}
+	return allErrs
+}
+
+func (rule *PolicyRule) validate(name string, secret *corev1.Secret) field.ErrorList {
+	allErrs := field.ErrorList{}
+	dataPath := field.NewPath("data")
+
+	for _, label := range rule.RequiredLabels {
+		if secret.Labels[label] == "" {
+			allErrs = append(allErrs, field.Required(field.NewPath("metadata", "labels").Key(label), fmt.Sprintf("required by policy %q", name)))
+		}
+	}
+
+	for _, key := range SortedKeys(stringMap(secret.Data)) {
+		if matchAny(rule.ForbiddenKeys, key) {
+			allErrs = append(allErrs, field.Forbidden(dataPath.Key(key), fmt.Sprintf("key name is forbidden by policy %q", name)))
+		}
+		if rule.MaxValueSize > 0 && len(secret.Data[key]) > rule.MaxValueSize {
+			allErrs = append(allErrs, field.TooLong(dataPath.Key(key), "<secret contents redacted>", rule.MaxValueSize))
+		}
+	}
+
+	if len(rule.AllowedNamespaces) > 0 && !matchAny(rule.AllowedNamespaces, secret.Namespace) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "namespace"), fmt.Sprintf("namespace %q is not allowed by policy %q", secret.Namespace, name)))
+	}
+
+	if len(rule.AllowedScopes) > 0 {
+		scope := ssv1alpha1.SecretScope(secret)
+		if !containsString(rule.AllowedScopes, scope.String()) {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("metadata", "annotations"), fmt.Sprintf("scope %s is not allowed by policy %q, allowed scopes are %v", scope.String(), name, rule.AllowedScopes)))
+		}
+	}
+
+	return allErrs
+}
+
+func matchAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, s); matched {
+			return true
+		}
+	}
+	return false
+}
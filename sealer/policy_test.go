@@ -0,0 +1,114 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	policyYAML := `
+rules:
+- name: ownership
+  requiredLabels: [team, app]
+- name: production
+  namespaces: ["prod-*"]
+  allowedScopes: [strict]
+  forbiddenKeys: ["*password*"]
+  maxValueSize: 8
+- allowedNamespaces: ["prod-*", "dev"]
+`
+	policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyFile, []byte(policyYAML), 0600); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicy(policyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validSecret := func() core.Secret {
+		return core.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "prod-eu",
+				Labels:    map[string]string{"team": "a", "app": "b"},
+			},
+			Data: map[string][]byte{
+				"token": []byte("bar"),
+			},
+		}
+	}
+
+	var (
+		missingLabel     = validSecret()
+		forbiddenKey     = validSecret()
+		tooLarge         = validSecret()
+		clusterWide      = validSecret()
+		notAllowedNs     = validSecret()
+		outsideProdRules = validSecret()
+	)
+	delete(missingLabel.Labels, "app")
+	forbiddenKey.Data["db_password"] = []byte("bar")
+	tooLarge.Data["token"] = []byte("123456789")
+	clusterWide.Annotations = map[string]string{"sealedsecrets.bitnami.com/cluster-wide": "true"}
+	notAllowedNs.Namespace = "staging"
+	outsideProdRules.Namespace = "dev"
+	outsideProdRules.Data["password"] = []byte("123456789")
+
+	tests := map[string]struct {
+		secret      core.Secret
+		valid       bool
+		errorDetail string
+	}{
+		"valid":                      {validSecret(), true, ""},
+		"missing label":              {missingLabel, false, `metadata.labels[app]: Required value: required by policy "ownership"`},
+		"forbidden key":              {forbiddenKey, false, `data[db_password]: Forbidden: key name is forbidden by policy "production"`},
+		"too large value":            {tooLarge, false, "data[token]: Too long"},
+		"cluster-wide scope":         {clusterWide, false, "scope cluster-wide is not allowed"},
+		"namespace not allowed":      {notAllowedNs, false, `namespace "staging" is not allowed by policy "rules[2]"`},
+		"rule limited to namespaces": {outsideProdRules, true, ""},
+	}
+
+	for name, tc := range tests {
+		errs := policy.Validate(&tc.secret)
+		if tc.valid && len(errs) > 0 {
+			t.Errorf("%v: Unexpected error: %v", name, errs)
+		}
+		if !tc.valid {
+			if len(errs) == 0 {
+				t.Errorf("%v: Unexpected non-error", name)
+			} else if !strings.Contains(errs.ToAggregate().Error(), tc.errorDetail) {
+				t.Errorf("%v: Expected error with detail %q, got %v", name, tc.errorDetail, errs)
+			}
+		}
+	}
+
+	// nil policy accepts everything
+	var nilPolicy *Policy
+	secret := validSecret()
+	if errs := nilPolicy.Validate(&secret); len(errs) > 0 {
+		t.Errorf("nil policy: Unexpected error: %v", errs)
+	}
+}
+
+func TestLoadPolicyErrors(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":   "rules:\n- requiredLabel: [team]\n",
+		"invalid pattern": "rules:\n- forbiddenKeys: [\"[\"]\n",
+		"invalid scope":   "rules:\n- allowedScopes: [everywhere]\n",
+	}
+	for name, policyYAML := range tests {
+		policyFile := filepath.Join(t.TempDir(), "policy.yaml")
+		if err := os.WriteFile(policyFile, []byte(policyYAML), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadPolicy(policyFile); err == nil {
+			t.Errorf("%v: Unexpected non-error", name)
+		}
+	}
+}
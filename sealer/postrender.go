@@ -0,0 +1,140 @@
+package sealer
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+// prefix of annotations helm acts on, e.g. helm.sh/hook. they must be on the SealedSecret itself,
+// since helm doesn't know what's in spec.template.
+const helmAnnotationPrefix = "helm.sh/"
+
+// SecretFilter selects Secrets to seal. zero value selects all Secrets.
+type SecretFilter struct {
+	// label selector, e.g. app=foo,tier!=cache
+	Selector labels.Selector
+	// glob patterns of names
+	Names []string
+}
+
+func (f *SecretFilter) Matches(secret *corev1.Secret) bool {
+	if f.Selector != nil && !f.Selector.Matches(labels.Set(secret.Labels)) {
+		return false
+	}
+	if len(f.Names) == 0 {
+		return true
+	}
+	for _, pattern := range f.Names {
+		if matched, _ := path.Match(pattern, secret.Name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// replace Secrets selected by filter in multi-document YAML, such as helm output, with SealedSecrets.
+// other documents are kept byte for byte, and so are comments leading each document, like `# Source:`
+// of helm. documents consisting only of comments are dropped. namespace is set to Secrets without one;
+// it's required for them unless they are sealed cluster-wide.
+// seal is given the Secret YAML, and returns the SealedSecret YAML.
+// returns the resulting YAML and the number of sealed Secrets.
+func SealSecretsInManifests(manifests []byte, filter SecretFilter, namespace string, seal func([]byte) ([]byte, error)) ([]byte, int, error) {
+	var output bytes.Buffer
+	sealed := 0
+	for i, doc := range SplitYAMLDocuments(manifests) {
+		if i > 0 {
+			output.WriteString("---\n")
+		}
+
+		output.Write(doc.Preamble)
+		content := doc.Content
+		secret, err := plainSecretFromYAML(content)
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %v", doc.Line, err)
+		}
+		if secret != nil && filter.Matches(secret) {
+			content, err = sealManifestSecret(secret, namespace, seal)
+			if err != nil {
+				return nil, 0, fmt.Errorf("line %d: Secret/%s: %v", doc.Line, secret.Name, err)
+			}
+			sealed++
+		}
+
+		output.Write(content)
+		if !bytes.HasSuffix(content, []byte("\n")) {
+			output.WriteString("\n")
+		}
+	}
+	return output.Bytes(), sealed, nil
+}
+
+// returns nil if the document is not a v1 Secret
+func plainSecretFromYAML(doc []byte) (*corev1.Secret, error) {
+	var typeMeta metav1.TypeMeta
+	if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml: %v", err)
+	}
+	if typeMeta.APIVersion != "v1" || typeMeta.Kind != "Secret" {
+		return nil, nil
+	}
+	var secret corev1.Secret
+	if err := yaml.UnmarshalStrict(doc, &secret); err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+	return &secret, nil
+}
+
+// labels and annotations end up in spec.template as kubeseal does, and helm annotations on the SealedSecret as well
+func sealManifestSecret(secret *corev1.Secret, namespace string, seal func([]byte) ([]byte, error)) ([]byte, error) {
+	if secret.Namespace == "" {
+		secret.Namespace = namespace
+	}
+	// kubeseal would fall back to the namespace of the kubeconfig context, which is not necessarily
+	// where helm installs the release, and the controller would fail to decrypt it
+	if secret.Namespace == "" && ssv1alpha1.SecretScope(secret) != ssv1alpha1.ClusterWideScope {
+		return nil, fmt.Errorf("namespace is required unless sealed cluster-wide; set it on the Secret or give --namespace")
+	}
+	secretYAML, err := yaml.Marshal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling kubernetes Secret to YAML: %v", err)
+	}
+	sealedSecretYAML, err := seal(secretYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	helmAnnotations := map[string]string{}
+	for k, v := range secret.Annotations {
+		if strings.HasPrefix(k, helmAnnotationPrefix) {
+			helmAnnotations[k] = v
+		}
+	}
+	if len(helmAnnotations) == 0 {
+		return sealedSecretYAML, nil
+	}
+
+	var sealedSecret ssv1alpha1.SealedSecret
+	err = yaml.UnmarshalStrict(sealedSecretYAML, &sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error unmarshalling yaml to SealedSecret: %v", err)
+	}
+	if sealedSecret.Annotations == nil {
+		sealedSecret.Annotations = map[string]string{}
+	}
+	for k, v := range helmAnnotations {
+		sealedSecret.Annotations[k] = v
+	}
+	sealedSecretYAML, err = yaml.Marshal(sealedSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling SealedSecret to YAML: %v", err)
+	}
+	return CanonicalizeSealedSecretYAML(sealedSecretYAML)
+}
@@ -0,0 +1,184 @@
+package sealer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+)
+
+const testManifests = `---
+# Source: chart/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name:   config
+data:
+  foo: bar
+---
+# Source: chart/templates/secret.yaml
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-db
+  labels:
+    app: db
+  annotations:
+    helm.sh/hook: pre-install
+    team: a
+stringData:
+  password: hunter2
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: app-cache
+  namespace: cache
+  labels:
+    app: cache
+stringData:
+  password: hunter2
+`
+
+// pretends to seal, keeping metadata in the template as kubeseal does
+func fakeSealManifest(secretYAML []byte) ([]byte, error) {
+	var secret corev1.Secret
+	if err := yaml.UnmarshalStrict(secretYAML, &secret); err != nil {
+		return nil, err
+	}
+	sealedSecret := ssv1alpha1.SealedSecret{}
+	sealedSecret.APIVersion = "bitnami.com/v1alpha1"
+	sealedSecret.Kind = "SealedSecret"
+	sealedSecret.Name = secret.Name
+	sealedSecret.Namespace = secret.Namespace
+	sealedSecret.Spec.Template.ObjectMeta = secret.ObjectMeta
+	sealedSecret.Spec.EncryptedData = map[string]string{}
+	for k := range secret.StringData {
+		sealedSecret.Spec.EncryptedData[k] = "sealed"
+	}
+	return yaml.Marshal(sealedSecret)
+}
+
+func TestSealSecretsInManifests(t *testing.T) {
+	tests := map[string]struct {
+		filter   SecretFilter
+		expected []string
+	}{
+		"all":      {SecretFilter{}, []string{"app-db", "app-cache"}},
+		"selector": {SecretFilter{Selector: labels.SelectorFromSet(labels.Set{"app": "db"})}, []string{"app-db"}},
+		"name":     {SecretFilter{Names: []string{"*-cache"}}, []string{"app-cache"}},
+		"none":     {SecretFilter{Names: []string{"other"}}, []string{}},
+	}
+	for name, test := range tests {
+		output, sealed, err := SealSecretsInManifests([]byte(testManifests), test.filter, "app", fakeSealManifest)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if sealed != len(test.expected) {
+			t.Errorf("%v: expected %d sealed, got %d", name, len(test.expected), sealed)
+		}
+
+		docs := SplitYAMLDocuments(output)
+		if len(docs) != 3 {
+			t.Errorf("%v: expected 3 documents, got %d:\n%s", name, len(docs), output)
+			continue
+		}
+		if !strings.Contains(string(docs[0].Content), "name:   config") {
+			t.Errorf("%v: expected ConfigMap to be kept as-is, got:\n%s", name, docs[0].Content)
+		}
+		sealedNames := []string{}
+		for _, doc := range docs[1:] {
+			kind, err := DocumentKind(doc.Content)
+			if err != nil {
+				t.Errorf("%v: Unexpected error: %v", name, err)
+			}
+			if kind == "SealedSecret" {
+				var sealedSecret ssv1alpha1.SealedSecret
+				if err := yaml.UnmarshalStrict(doc.Content, &sealedSecret); err != nil {
+					t.Errorf("%v: Unexpected error: %v", name, err)
+				}
+				sealedNames = append(sealedNames, sealedSecret.Name)
+			}
+		}
+		if !reflect.DeepEqual(sealedNames, test.expected) {
+			t.Errorf("%v: expected %v to be sealed, got %v", name, test.expected, sealedNames)
+		}
+	}
+}
+
+func TestSealSecretsInManifestsMetadata(t *testing.T) {
+	output, _, err := SealSecretsInManifests([]byte(testManifests), SecretFilter{Names: []string{"app-db"}}, "app", fakeSealManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sealedSecret ssv1alpha1.SealedSecret
+	if err := yaml.UnmarshalStrict(SplitYAMLDocuments(output)[1].Content, &sealedSecret); err != nil {
+		t.Fatal(err)
+	}
+	if sealedSecret.Namespace != "app" {
+		t.Errorf("expected namespace to default to app, got %q", sealedSecret.Namespace)
+	}
+	if sealedSecret.Annotations["helm.sh/hook"] != "pre-install" || sealedSecret.Annotations["team"] != "" {
+		t.Errorf("expected only helm annotations on SealedSecret, got %v", sealedSecret.Annotations)
+	}
+	template := sealedSecret.Spec.Template.ObjectMeta
+	if template.Labels["app"] != "db" || template.Annotations["team"] != "a" {
+		t.Errorf("expected labels and annotations in template, got %v %v", template.Labels, template.Annotations)
+	}
+}
+
+func TestSealSecretsInManifestsPreamble(t *testing.T) {
+	output, _, err := SealSecretsInManifests([]byte(testManifests), SecretFilter{}, "app", fakeSealManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := SplitYAMLDocuments(output)
+	if len(docs) != 3 {
+		t.Fatalf("expected 3 documents, got %d:\n%s", len(docs), output)
+	}
+	for i, expected := range []string{"# Source: chart/templates/configmap.yaml\n", "# Source: chart/templates/secret.yaml\n", ""} {
+		if string(docs[i].Preamble) != expected {
+			t.Errorf("document %d: expected comments before it to be kept, got %q", i, docs[i].Preamble)
+		}
+	}
+}
+
+func TestSealSecretsInManifestsNamespace(t *testing.T) {
+	const secret = "apiVersion: v1\nkind: Secret\nmetadata:\n  name: foo\n%s\nstringData:\n  password: hunter2\n"
+	tests := map[string]struct {
+		metadata          string
+		namespace         string
+		expectedNamespace string
+		expectedError     bool
+	}{
+		"namespace of Secret":        {metadata: "  namespace: db", namespace: "app", expectedNamespace: "db"},
+		"given namespace":            {namespace: "app", expectedNamespace: "app"},
+		"no namespace":               {expectedError: true},
+		"no namespace, cluster-wide": {metadata: "  annotations:\n    sealedsecrets.bitnami.com/cluster-wide: \"true\""},
+	}
+	for name, test := range tests {
+		output, _, err := SealSecretsInManifests([]byte(strings.Replace(secret, "%s\n", test.metadata+"\n", 1)), SecretFilter{}, test.namespace, fakeSealManifest)
+		if test.expectedError {
+			if err == nil {
+				t.Errorf("%v: expected error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		var sealedSecret ssv1alpha1.SealedSecret
+		if err := yaml.UnmarshalStrict(output, &sealedSecret); err != nil {
+			t.Fatal(err)
+		}
+		if sealedSecret.Namespace != test.expectedNamespace {
+			t.Errorf("%v: expected namespace %q, got %q", name, test.expectedNamespace, sealedSecret.Namespace)
+		}
+	}
+}
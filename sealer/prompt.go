@@ -0,0 +1,91 @@
+package sealer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// shared among prompts, since a reader per prompt would lose input buffered by the previous one
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// ask the user to choose one of the given choices, each identified by its first letter.
+// keeps asking until a valid answer is given. returns io.EOF if stdin is closed.
+func promptChoice(question string, choices ...string) (byte, error) {
+	for {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, strings.Join(choices, " / "))
+		line, err := stdinReader.ReadString('\n')
+		answer := strings.ToLower(strings.TrimSpace(line))
+		if len(answer) > 0 {
+			for _, choice := range choices {
+				if answer[0] == strings.Trim(choice, "()")[0] {
+					return answer[0], nil
+				}
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				fmt.Fprintln(os.Stderr)
+			}
+			return 0, err
+		}
+	}
+}
+
+// ask a yes/no question. anything but yes, including closed stdin, is treated as no.
+func Confirm(question string) (bool, error) {
+	answer, err := promptChoice(question, "(y)es", "(n)o")
+	if err != nil {
+		if err == io.EOF {
+			return false, nil
+		}
+		return false, fmt.Errorf("error reading answer: %v", err)
+	}
+	return answer == 'y', nil
+}
+
+// ask for a secret value without echoing it back. if stdin is not a terminal,
+// a single line is read from it instead, so that the value can be piped in.
+func PromptSecret(question string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return readLine(question)
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: ", question)
+	value, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", question, err)
+	}
+	return string(value), nil
+}
+
+// ask for a value, falling back to defaultValue if the answer is empty
+func Prompt(question string, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", question, defaultValue)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", question)
+	}
+	value, err := readLine(question)
+	if err != nil {
+		return "", err
+	}
+	if value == "" {
+		return defaultValue, nil
+	}
+	return value, nil
+}
+
+func readLine(question string) (string, error) {
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("error reading %s: %v", question, err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,136 @@
+package sealer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ScaffoldOptions holds values to fill a new Secret with, so that it can be created without an editor.
+// which fields are given determines the type of the Secret.
+type ScaffoldOptions struct {
+	// kubernetes.io/tls
+	CertFile string
+	KeyFile  string
+
+	// kubernetes.io/dockerconfigjson
+	DockerServer   string
+	DockerUsername string
+	DockerPassword string
+	DockerEmail    string
+
+	// kubernetes.io/basic-auth
+	Username string
+	Password string
+
+	// kubernetes.io/ssh-auth
+	SSHKeyFile string
+}
+
+// returns type of the Secret the options are meant for, or empty string if none is given
+func (o *ScaffoldOptions) SecretType() (corev1.SecretType, error) {
+	types := []corev1.SecretType{}
+	if o.CertFile != "" || o.KeyFile != "" {
+		types = append(types, corev1.SecretTypeTLS)
+	}
+	if o.DockerServer != "" || o.DockerUsername != "" || o.DockerPassword != "" || o.DockerEmail != "" {
+		types = append(types, corev1.SecretTypeDockerConfigJson)
+	}
+	if o.Username != "" || o.Password != "" {
+		types = append(types, corev1.SecretTypeBasicAuth)
+	}
+	if o.SSHKeyFile != "" {
+		types = append(types, corev1.SecretTypeSSHAuth)
+	}
+
+	switch len(types) {
+	case 0:
+		return "", nil
+	case 1:
+		return types[0], nil
+	default:
+		return "", fmt.Errorf("options for different Secret types are given: %v", types)
+	}
+}
+
+// build stringData of the Secret from the options. files are read here, but
+// the contents are checked later by ValidateSecret along with the rest of the Secret.
+func ScaffoldSecretData(secretType corev1.SecretType, o ScaffoldOptions) (map[string]string, error) {
+	optionsType, err := o.SecretType()
+	if err != nil {
+		return nil, err
+	}
+	if optionsType != secretType {
+		return nil, fmt.Errorf("options are for type %s, but the Secret is of type %s", optionsType, secretType)
+	}
+
+	stringData := map[string]string{}
+	switch secretType {
+	case corev1.SecretTypeTLS:
+		if o.CertFile == "" || o.KeyFile == "" {
+			return nil, fmt.Errorf("both certificate and key files are required for type %s", secretType)
+		}
+		if stringData[corev1.TLSCertKey], err = readScaffoldFile(o.CertFile); err != nil {
+			return nil, err
+		}
+		if stringData[corev1.TLSPrivateKeyKey], err = readScaffoldFile(o.KeyFile); err != nil {
+			return nil, err
+		}
+
+	case corev1.SecretTypeDockerConfigJson:
+		dockerConfigJSON, err := BuildDockerConfigJSON(o.DockerServer, o.DockerUsername, o.DockerPassword, o.DockerEmail)
+		if err != nil {
+			return nil, err
+		}
+		stringData[corev1.DockerConfigJsonKey] = dockerConfigJSON
+
+	case corev1.SecretTypeBasicAuth:
+		if o.Username == "" {
+			return nil, fmt.Errorf("username is required for type %s", secretType)
+		}
+		stringData[corev1.BasicAuthUsernameKey] = o.Username
+		stringData[corev1.BasicAuthPasswordKey] = o.Password
+
+	case corev1.SecretTypeSSHAuth:
+		if stringData[corev1.SSHAuthPrivateKey], err = readScaffoldFile(o.SSHKeyFile); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("no options are given")
+	}
+	return stringData, nil
+}
+
+// build .dockerconfigjson the same way as `kubectl create secret docker-registry` does
+func BuildDockerConfigJSON(server, username, password, email string) (string, error) {
+	if server == "" || username == "" || password == "" {
+		return "", fmt.Errorf("docker server, username and password are all required")
+	}
+	config := dockerConfigJSON{
+		Auths: map[string]dockerConfigEntry{
+			server: {
+				Username: username,
+				Password: password,
+				Email:    email,
+				Auth:     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+			},
+		},
+	}
+	dockerConfigJSONBytes, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling dockerconfigjson: %v", err)
+	}
+	return string(dockerConfigJSONBytes), nil
+}
+
+func readScaffoldFile(filename string) (string, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("error reading file: %v", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,75 @@
+package sealer
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestScaffoldOptionsSecretType(t *testing.T) {
+	tests := map[string]struct {
+		options  ScaffoldOptions
+		expected corev1.SecretType
+		err      bool
+	}{
+		"none":        {options: ScaffoldOptions{}, expected: ""},
+		"tls":         {options: ScaffoldOptions{CertFile: "tls.crt"}, expected: corev1.SecretTypeTLS},
+		"docker":      {options: ScaffoldOptions{DockerServer: "registry.example.com"}, expected: corev1.SecretTypeDockerConfigJson},
+		"basic-auth":  {options: ScaffoldOptions{Username: "admin"}, expected: corev1.SecretTypeBasicAuth},
+		"ssh":         {options: ScaffoldOptions{SSHKeyFile: "id_ed25519"}, expected: corev1.SecretTypeSSHAuth},
+		"conflicting": {options: ScaffoldOptions{Username: "admin", SSHKeyFile: "id_ed25519"}, err: true},
+	}
+
+	for name, tc := range tests {
+		secretType, err := tc.options.SecretType()
+		if tc.err {
+			if err == nil {
+				t.Errorf("%v: Expected error, got none", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+		}
+		if secretType != tc.expected {
+			t.Errorf("%v: expected %q, got %q", name, tc.expected, secretType)
+		}
+	}
+}
+
+func TestScaffoldSecretData(t *testing.T) {
+	dockerOptions := ScaffoldOptions{DockerServer: "registry.example.com", DockerUsername: "user", DockerPassword: "pass:word"}
+	stringData, err := ScaffoldSecretData(corev1.SecretTypeDockerConfigJson, dockerOptions)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if errs := validateDockerConfigJSON([]byte(stringData[corev1.DockerConfigJsonKey]), nil); len(errs) > 0 {
+		t.Errorf("Unexpected error: %v", errs.ToAggregate())
+	}
+	var config dockerConfigJSON
+	if err := json.Unmarshal([]byte(stringData[corev1.DockerConfigJsonKey]), &config); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if config.Auths["registry.example.com"].Auth != "dXNlcjpwYXNzOndvcmQ=" {
+		t.Errorf("unexpected auth: %q", config.Auths["registry.example.com"].Auth)
+	}
+
+	stringData, err = ScaffoldSecretData(corev1.SecretTypeSSHAuth, ScaffoldOptions{SSHKeyFile: "testdata/ssh/id_ed25519"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if errs := validateSSHPrivateKey([]byte(stringData[corev1.SSHAuthPrivateKey]), nil); len(errs) > 0 {
+		t.Errorf("Unexpected error: %v", errs.ToAggregate())
+	}
+
+	if _, err := ScaffoldSecretData(corev1.SecretTypeTLS, ScaffoldOptions{CertFile: "tls.crt"}); err == nil {
+		t.Errorf("Expected error for missing key file, got none")
+	}
+	if _, err := ScaffoldSecretData(corev1.SecretTypeOpaque, ScaffoldOptions{Username: "admin"}); err == nil {
+		t.Errorf("Expected error for type mismatch, got none")
+	}
+	if _, err := ScaffoldSecretData(corev1.SecretTypeDockerConfigJson, ScaffoldOptions{DockerServer: "registry.example.com"}); err == nil {
+		t.Errorf("Expected error for missing credentials, got none")
+	}
+}
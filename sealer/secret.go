@@ -1,50 +1,155 @@
 package sealer
 
 import (
-	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/yaml"
 )
 
-func EditSecretUntilOK(secretYAML []byte) ([]byte, error) {
+// ErrEditCanceled is returned when the user aborts editing, either by saving an
+// empty buffer or by choosing to discard the changes after a validation failure.
+var ErrEditCanceled = errors.New("edit canceled")
+
+// open the Secret in editor until it expands and passes validation and the policy, which may be nil.
+// returns the expanded Secret.
+func EditSecretUntilOK(secretYAML []byte, editor string, policy *Policy) ([]byte, error) {
 	for {
-		editedSecretYAML, err := EditWithEditor(secretYAML)
+		editedSecretYAML, err := EditWithEditor(secretYAML, editor)
 		if err != nil {
 			return nil, err
 		}
 
-		validationErrors, err := ValidateSecretYAML(editedSecretYAML)
-		if err != nil {
-			return nil, err
+		// an empty buffer means cancel, just like `git commit` does
+		if len(bytes.TrimSpace(editedSecretYAML)) == 0 {
+			return nil, ErrEditCanceled
 		}
 
-		if len(validationErrors) > 0 {
-			log.Printf("validation failed: %v\nPress any key to return to the editor, or Ctrl+C to exit.", validationErrors)
-			bufio.NewReader(os.Stdin).ReadByte()
+		// expand generators, then derivations so that generated values can be derived from, and check
+		// the result. any failure, including malformed yaml, is reported as a validation failure, and
+		// editing again starts from the unexpanded buffer so that generated values don't show up.
+		expandedSecretYAML, err := ExpandGenerators(editedSecretYAML)
+		if err == nil {
+			expandedSecretYAML, err = ApplyDerivations(expandedSecretYAML)
+		}
+		if err == nil {
+			err = CheckSecretYAML(expandedSecretYAML, policy)
+			if err == nil {
+				return expandedSecretYAML, nil
+			}
+		}
+
+		log.Printf("validation failed: %v", err)
+		answer, err := promptChoice("What now?", "(e)dit again", "(d)iscard", "(s)ave raw to backup file")
+		if err != nil {
+			if err == io.EOF {
+				return nil, ErrEditCanceled
+			}
+			return nil, fmt.Errorf("error reading answer: %v", err)
+		}
+		switch answer {
+		case 'e':
 			secretYAML = editedSecretYAML
 			continue
-		} else {
-			return editedSecretYAML, nil
+		case 'd':
+			return nil, ErrEditCanceled
+		case 's':
+			backupFilename, err := saveRawBackup(editedSecretYAML)
+			if err != nil {
+				return nil, err
+			}
+			log.Printf("saved raw buffer to %s; it contains PLAINTEXT secrets, remove it once you are done", backupFilename)
+			return nil, ErrEditCanceled
 		}
 	}
 }
 
+// check the Secret the same way as the edit loop does: it must be well-formed, pass validation and
+// the policy, which may be nil. warnings are logged if it's ok.
+func CheckSecretYAML(secretYAML []byte, policy *Policy) error {
+	secret, err := secretFromYAML(secretYAML)
+	if err != nil {
+		return err
+	}
+	if validationErrors := ValidateSecret(secret); len(validationErrors) > 0 {
+		return validationErrors.ToAggregate()
+	}
+	if policyErrors := policy.Validate(secret); len(policyErrors) > 0 {
+		return fmt.Errorf("policy violation: %v", policyErrors.ToAggregate())
+	}
+	for _, warning := range GetWarningsForSecret(secret) {
+		log.Printf("warning: %s", warning)
+	}
+	return nil
+}
+
+// save the edit buffer as-is into a private directory in the user cache dir, so that the work is
+// not lost. it's never saved in the current directory, which is likely a git repository.
+func saveRawBackup(content []byte) (filename string, err error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("error creating backup file: %v", err)
+	}
+	dir := filepath.Join(cacheDir, "kubectl-sealer", "backups")
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", fmt.Errorf("error creating backup directory: %v", err)
+	}
+	// MkdirAll leaves the mode of an existing directory as-is
+	err = os.Chmod(dir, 0700)
+	if err != nil {
+		return "", fmt.Errorf("error creating backup directory: %v", err)
+	}
+
+	// os.CreateTemp creates the file with 0600
+	f, err := os.CreateTemp(dir, "edit-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("error creating backup file: %v", err)
+	}
+	_, err = f.Write(content)
+	if err != nil {
+		f.Close()
+		return "", fmt.Errorf("error writing backup file: %s: %v", f.Name(), err)
+	}
+	err = f.Close()
+	if err != nil {
+		return "", fmt.Errorf("error writing backup file: %s: %v", f.Name(), err)
+	}
+	return f.Name(), nil
+}
+
 func ValidateSecretYAML(secretYAML []byte) (field.ErrorList, error) {
+	secret, err := secretFromYAML(secretYAML)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateSecret(secret), nil
+}
+
+// build Secret from the edit buffer, merging stringData into data so that every key is validated
+func secretFromYAML(secretYAML []byte) (*corev1.Secret, error) {
 	var secret corev1.Secret
 	err := yaml.UnmarshalStrict(secretYAML, &secret)
 	if err != nil {
 		return nil, fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
 	}
 
-	secret.Data = map[string][]byte{}
+	data := map[string][]byte{}
+	for k, v := range secret.Data {
+		data[k] = v
+	}
+	// stringData takes precedence, the same as the API server does
 	for k, v := range secret.StringData {
-		secret.Data[k] = []byte(v)
+		data[k] = []byte(v)
 	}
+	secret.Data = data
 
-	return ValidateSecret(&secret), nil
+	return &secret, nil
 }
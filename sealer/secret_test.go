@@ -0,0 +1,173 @@
+package sealer
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// unknown field makes the edit buffer fail validation
+const testInvalidSecret = `apiVersion: v1
+kind: Secret
+metadata:
+  name: foo
+  namespace: app
+stringData:
+  password: hunter2
+bogus: true
+`
+
+func TestEditSecretUntilOKInvalid(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("editor is a shell script")
+	}
+	dir := t.TempDir()
+	editor := filepath.Join(dir, "editor")
+	err := os.WriteFile(editor, []byte("#!/bin/sh\ncat > \"$1\" <<'EOF'\n"+testInvalidSecret+"EOF\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// backups must go to the user cache dir, never to the current directory
+	for _, key := range []string{"XDG_CACHE_HOME", "HOME"} {
+		original, exists := os.LookupEnv(key)
+		os.Setenv(key, filepath.Join(dir, "home"))
+		defer func(key string) {
+			if exists {
+				os.Setenv(key, original)
+			} else {
+				os.Unsetenv(key)
+			}
+		}(key)
+	}
+	workDir := filepath.Join(dir, "work")
+	if err := os.Mkdir(workDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	originalWorkDir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(workDir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalWorkDir)
+	defer func() { stdinReader = bufio.NewReader(os.Stdin) }()
+
+	tests := map[string]struct {
+		answers      string
+		expectBackup bool
+	}{
+		"discard":                  {answers: "d\n"},
+		"closed stdin":             {answers: ""},
+		"edit again, then discard": {answers: "e\nd\n"},
+		"save raw":                 {answers: "s\n", expectBackup: true},
+	}
+
+	for name, test := range tests {
+		stdinReader = bufio.NewReader(strings.NewReader(test.answers))
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			t.Fatal(err)
+		}
+		backupDir := filepath.Join(cacheDir, "kubectl-sealer", "backups")
+		os.RemoveAll(backupDir)
+
+		_, err = EditSecretUntilOK([]byte(testInvalidSecret), editor, nil)
+		if !errors.Is(err, ErrEditCanceled) {
+			t.Errorf("%v: expected ErrEditCanceled, got: %v", name, err)
+		}
+
+		if entries, _ := os.ReadDir(workDir); len(entries) != 0 {
+			t.Errorf("%v: expected nothing in the current directory, got %v", name, entries)
+		}
+
+		backups, _ := filepath.Glob(filepath.Join(backupDir, "*.yaml"))
+		if !test.expectBackup {
+			if len(backups) != 0 {
+				t.Errorf("%v: expected no backup, got %v", name, backups)
+			}
+			continue
+		}
+		if len(backups) != 1 {
+			t.Errorf("%v: expected a backup, got %v", name, backups)
+			continue
+		}
+		content, err := os.ReadFile(backups[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != testInvalidSecret {
+			t.Errorf("%v: expected raw buffer in backup, got:\n%s", name, content)
+		}
+		for _, path := range []string{backups[0], backupDir} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0077 != 0 {
+				t.Errorf("%v: expected %s to be private, got %v", name, path, info.Mode().Perm())
+			}
+		}
+	}
+}
+
+func TestCheckSecretYAML(t *testing.T) {
+	c := newTestCertificate(t, "example.com", []string{"example.com"}, time.Now().Add(365*24*time.Hour), nil)
+	policy := &Policy{Rules: []PolicyRule{{Name: "no passwords", ForbiddenKeys: []string{"*password*"}, MaxValueSize: 4096}}}
+
+	tests := map[string]struct {
+		secret        corev1.Secret
+		expectedError string
+	}{
+		"tls in data": {
+			secret: corev1.Secret{Type: corev1.SecretTypeTLS, Data: map[string][]byte{"tls.crt": c.certPEM, "tls.key": c.keyPEM}},
+		},
+		"tls in data and stringData": {
+			secret: corev1.Secret{Type: corev1.SecretTypeTLS, Data: map[string][]byte{"tls.crt": c.certPEM}, StringData: map[string]string{"tls.key": string(c.keyPEM)}},
+		},
+		"tls in data, missing key": {
+			secret:        corev1.Secret{Type: corev1.SecretTypeTLS, Data: map[string][]byte{"tls.crt": c.certPEM}},
+			expectedError: "data[tls.key]: Required value",
+		},
+		"forbidden key in data": {
+			secret:        corev1.Secret{Data: map[string][]byte{"db-password": []byte("hunter2")}},
+			expectedError: "data[db-password]: Forbidden",
+		},
+		"too long value in data": {
+			secret:        corev1.Secret{Data: map[string][]byte{"blob": make([]byte, 4097)}},
+			expectedError: "data[blob]: Too long",
+		},
+		"stringData takes precedence": {
+			secret: corev1.Secret{Data: map[string][]byte{"blob": make([]byte, 4097)}, StringData: map[string]string{"blob": "short"}},
+		},
+	}
+
+	for name, test := range tests {
+		test.secret.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}
+		test.secret.ObjectMeta = metav1.ObjectMeta{Name: "foo", Namespace: "app"}
+		secretYAML, err := yaml.Marshal(test.secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = CheckSecretYAML(secretYAML, policy)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("%v: Unexpected error: %v", name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("%v: expected error %q, got: %v", name, test.expectedError, err)
+		}
+	}
+}
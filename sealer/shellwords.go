@@ -0,0 +1,79 @@
+package sealer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// split a string into words the way POSIX shell does, honoring single quotes,
+// double quotes and backslash escapes. no expansion of variables or globs is performed.
+// e.g. `code --wait` -> ["code", "--wait"]
+func splitShellWords(s string) (words []string, err error) {
+	words = []string{}
+
+	var word strings.Builder
+	// whether we are in the middle of a word; needed to keep empty quoted words like ''
+	inWord := false
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+
+		case r == '\\':
+			i++
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated backslash escape: %s", s)
+			}
+			// backslash-newline is a line continuation
+			if runes[i] != '\n' {
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+
+		case r == '\'':
+			// everything up to the next single quote is taken literally
+			end := i + 1
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated single quote: %s", s)
+			}
+			word.WriteString(string(runes[i+1 : end]))
+			inWord = true
+			i = end
+
+		case r == '"':
+			// backslash only escapes $, `, ", \ and newline within double quotes
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated double quote: %s", s)
+			}
+			inWord = true
+
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
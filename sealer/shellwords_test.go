@@ -0,0 +1,53 @@
+package sealer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		words []string
+		valid bool
+	}{
+		"empty":                       {``, []string{}, true},
+		"blank":                       {" \t ", []string{}, true},
+		"single word":                 {`vi`, []string{"vi"}, true},
+		"with arguments":              {`code --wait`, []string{"code", "--wait"}, true},
+		"extra whitespace":            {"  emacsclient \t -t  ", []string{"emacsclient", "-t"}, true},
+		"single quoted path":          {`'/Applications/Sublime Text.app/subl' -w`, []string{"/Applications/Sublime Text.app/subl", "-w"}, true},
+		"double quoted path":          {`"/opt/my editor/bin/edit" --wait`, []string{"/opt/my editor/bin/edit", "--wait"}, true},
+		"escaped space":               {`/opt/my\ editor/edit`, []string{"/opt/my editor/edit"}, true},
+		"quotes within word":          {`vim -c'set ft=yaml'`, []string{"vim", "-cset ft=yaml"}, true},
+		"empty single quoted":         {`edit ''`, []string{"edit", ""}, true},
+		"empty double quoted":         {`edit ""`, []string{"edit", ""}, true},
+		"backslash in single quotes":  {`'a\b'`, []string{`a\b`}, true},
+		"escaped quote in double":     {`"a\"b"`, []string{`a"b`}, true},
+		"other backslash in double":   {`"a\b"`, []string{`a\b`}, true},
+		"single quote in double":      {`"it's"`, []string{`it's`}, true},
+		"line continuation":           {"vim \\\n-u NONE", []string{"vim", "-u", "NONE"}, true},
+		"unterminated single quote":   {`'vim`, nil, false},
+		"unterminated double quote":   {`"vim`, nil, false},
+		"unterminated backslash":      {`vim\`, nil, false},
+		"unterminated nested quote":   {`"it's`, nil, false},
+		"adjacent quoted and unquote": {`a"b c"'d e'f`, []string{"ab cd ef"}, true},
+	}
+
+	for name, tc := range tests {
+		words, err := splitShellWords(tc.input)
+		if tc.valid && err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if !tc.valid {
+			if err == nil {
+				t.Errorf("%v: Unexpected non-error, got %q", name, words)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(words, tc.words) {
+			t.Errorf("%v: Expected %q, got %q", name, tc.words, words)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package sealer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	ssv1alpha1 "github.com/bitnami-labs/sealed-secrets/pkg/apis/sealed-secrets/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// describe the changes between two Secrets in a human readable form.
+// secret values are never printed, only the names of added/removed/changed keys.
+func DescribeSecretChanges(secretYAML []byte, editedSecretYAML []byte) (string, error) {
+	var secret corev1.Secret
+	err := yaml.UnmarshalStrict(secretYAML, &secret)
+	if err != nil {
+		return "", fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+
+	var editedSecret corev1.Secret
+	err = yaml.UnmarshalStrict(editedSecretYAML, &editedSecret)
+	if err != nil {
+		return "", fmt.Errorf("error unmarshalling yaml to kubernetes Secret: %v", err)
+	}
+
+	lines := []string{}
+
+	// scope and metadata
+	scope := ssv1alpha1.SecretScope(&secret)
+	editedScope := ssv1alpha1.SecretScope(&editedSecret)
+	if scope != editedScope {
+		lines = append(lines, fmt.Sprintf("  scope: %s -> %s", scope.String(), editedScope.String()))
+	}
+	if secret.Name != editedSecret.Name {
+		lines = append(lines, fmt.Sprintf("  metadata.name: %s -> %s", secret.Name, editedSecret.Name))
+	}
+	if secret.Namespace != editedSecret.Namespace {
+		lines = append(lines, fmt.Sprintf("  metadata.namespace: %s -> %s", secret.Namespace, editedSecret.Namespace))
+	}
+	if secret.Type != editedSecret.Type {
+		lines = append(lines, fmt.Sprintf("  type: %s -> %s", secret.Type, editedSecret.Type))
+	}
+	lines = append(lines, describeMapChanges("metadata.labels", secret.Labels, editedSecret.Labels)...)
+	lines = append(lines, describeMapChanges("metadata.annotations", secret.Annotations, editedSecret.Annotations)...)
+
+	// secret data; only key names are shown
+	addedKeys := GetKeyDiff(editedSecret.StringData, secret.StringData)
+	for _, k := range addedKeys {
+		lines = append(lines, fmt.Sprintf("  + stringData[%s] (added)", k))
+	}
+	removedKeys := GetKeyDiff(secret.StringData, editedSecret.StringData)
+	for _, k := range removedKeys {
+		lines = append(lines, fmt.Sprintf("  - stringData[%s] (removed)", k))
+	}
+	for _, k := range SortedKeys(GetUpdatedExisting(editedSecret.StringData, secret.StringData)) {
+		lines = append(lines, fmt.Sprintf("  ~ stringData[%s] (changed)", k))
+	}
+
+	if reason := FullResealReason(&secret, &editedSecret); reason != "" {
+		lines = append(lines, fmt.Sprintf("  ! all values will be re-encrypted: %s", reason))
+	}
+
+	if len(lines) == 0 {
+		return "no change\n", nil
+	}
+	return "changes:\n" + strings.Join(lines, "\n") + "\n", nil
+}
+
+// labels and annotations are not secret, so their values are shown as-is
+func describeMapChanges(path string, a map[string]string, b map[string]string) []string {
+	lines := []string{}
+	for _, k := range GetKeyDiff(b, a) {
+		lines = append(lines, fmt.Sprintf("  + %s[%s]: %s", path, k, b[k]))
+	}
+	for _, k := range GetKeyDiff(a, b) {
+		lines = append(lines, fmt.Sprintf("  - %s[%s]: %s", path, k, a[k]))
+	}
+	for k, v := range GetUpdatedExisting(b, a) {
+		lines = append(lines, fmt.Sprintf("  ~ %s[%s]: %s -> %s", path, k, a[k], v))
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		// sort by key, ignoring the leading marker
+		return lines[i][4:] < lines[j][4:]
+	})
+	return lines
+}
+
+// returns why the edited Secret cannot be partially updated and must be sealed entirely again,
+// or empty string if partial update is possible. existing ciphertexts are bound to the
+// scope and to name/namespace depending on the scope, so they become undecryptable otherwise.
+func FullResealReason(secret *corev1.Secret, editedSecret *corev1.Secret) string {
+	scope := ssv1alpha1.SecretScope(secret)
+	editedScope := ssv1alpha1.SecretScope(editedSecret)
+
+	// if scope has been changed
+	if scope != editedScope {
+		return fmt.Sprintf("scope has been changed from %s to %s", scope.String(), editedScope.String())
+	}
+
+	// if scope is strict, and either namespace or name has been changed
+	if editedScope == ssv1alpha1.StrictScope {
+		if secret.Namespace != editedSecret.Namespace || secret.Name != editedSecret.Name {
+			return fmt.Sprintf("name or namespace of a strict scoped Secret has been changed from %s/%s to %s/%s",
+				secret.Namespace, secret.Name, editedSecret.Namespace, editedSecret.Name)
+		}
+	}
+
+	// if scope is namespace-wide, and namespace has been changed
+	if editedScope == ssv1alpha1.NamespaceWideScope {
+		if secret.Namespace != editedSecret.Namespace {
+			return fmt.Sprintf("namespace of a namespace-wide scoped Secret has been changed from %s to %s",
+				secret.Namespace, editedSecret.Namespace)
+		}
+	}
+
+	return ""
+}
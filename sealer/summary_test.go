@@ -0,0 +1,162 @@
+package sealer
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	testNamespaceWide = "sealedsecrets.bitnami.com/namespace-wide"
+	testClusterWide   = "sealedsecrets.bitnami.com/cluster-wide"
+)
+
+func testSummarySecret(modify func(secret *corev1.Secret)) *corev1.Secret {
+	secret := &corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "app"},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: map[string]string{"username": "app", "password": "hunter2"},
+	}
+	if modify != nil {
+		modify(secret)
+	}
+	return secret
+}
+
+func TestFullResealReason(t *testing.T) {
+	scoped := func(annotation string) func(*corev1.Secret) {
+		return func(secret *corev1.Secret) {
+			secret.Annotations = map[string]string{annotation: "true"}
+		}
+	}
+	tests := map[string]struct {
+		secret       *corev1.Secret
+		editedSecret *corev1.Secret
+		expected     string
+	}{
+		"no change": {
+			secret:       testSummarySecret(nil),
+			editedSecret: testSummarySecret(nil),
+		},
+		"values changed": {
+			secret:       testSummarySecret(nil),
+			editedSecret: testSummarySecret(func(s *corev1.Secret) { s.StringData = map[string]string{"token": "new"} }),
+		},
+		"strict, name changed": {
+			secret:       testSummarySecret(nil),
+			editedSecret: testSummarySecret(func(s *corev1.Secret) { s.Name = "bar" }),
+			expected:     "name or namespace of a strict scoped Secret has been changed from app/foo to app/bar",
+		},
+		"strict, namespace changed": {
+			secret:       testSummarySecret(nil),
+			editedSecret: testSummarySecret(func(s *corev1.Secret) { s.Namespace = "other" }),
+			expected:     "name or namespace of a strict scoped Secret has been changed from app/foo to other/foo",
+		},
+		"namespace-wide, name changed": {
+			secret:       testSummarySecret(scoped(testNamespaceWide)),
+			editedSecret: testSummarySecret(func(s *corev1.Secret) { scoped(testNamespaceWide)(s); s.Name = "bar" }),
+		},
+		"namespace-wide, namespace changed": {
+			secret:       testSummarySecret(scoped(testNamespaceWide)),
+			editedSecret: testSummarySecret(func(s *corev1.Secret) { scoped(testNamespaceWide)(s); s.Namespace = "other" }),
+			expected:     "namespace of a namespace-wide scoped Secret has been changed from app to other",
+		},
+		"cluster-wide, name and namespace changed": {
+			secret:       testSummarySecret(scoped(testClusterWide)),
+			editedSecret: testSummarySecret(func(s *corev1.Secret) { scoped(testClusterWide)(s); s.Name = "bar"; s.Namespace = "other" }),
+		},
+		"scope changed": {
+			secret:       testSummarySecret(nil),
+			editedSecret: testSummarySecret(scoped(testClusterWide)),
+			expected:     "scope has been changed from strict to cluster-wide",
+		},
+	}
+
+	for name, test := range tests {
+		reason := FullResealReason(test.secret, test.editedSecret)
+		if reason != test.expected {
+			t.Errorf("%v: expected %q, got %q", name, test.expected, reason)
+		}
+	}
+}
+
+func TestDescribeSecretChanges(t *testing.T) {
+	tests := map[string]struct {
+		modify   func(secret *corev1.Secret)
+		expected []string
+	}{
+		"no change": {
+			expected: []string{"no change"},
+		},
+		"added key": {
+			modify:   func(s *corev1.Secret) { s.StringData["token"] = "t0ken" },
+			expected: []string{"changes:", "  + stringData[token] (added)"},
+		},
+		"removed key": {
+			modify:   func(s *corev1.Secret) { delete(s.StringData, "username") },
+			expected: []string{"changes:", "  - stringData[username] (removed)"},
+		},
+		"changed key": {
+			modify:   func(s *corev1.Secret) { s.StringData["password"] = "hunter3" },
+			expected: []string{"changes:", "  ~ stringData[password] (changed)"},
+		},
+		"labels and annotations": {
+			modify: func(s *corev1.Secret) {
+				s.Labels = map[string]string{"team": "a"}
+				s.Annotations = map[string]string{"owner": "b"}
+			},
+			expected: []string{"changes:", "  + metadata.labels[team]: a", "  + metadata.annotations[owner]: b"},
+		},
+		"type": {
+			modify:   func(s *corev1.Secret) { s.Type = corev1.SecretTypeBasicAuth },
+			expected: []string{"changes:", "  type: Opaque -> kubernetes.io/basic-auth"},
+		},
+		"name": {
+			modify: func(s *corev1.Secret) { s.Name = "bar" },
+			expected: []string{"changes:", "  metadata.name: foo -> bar",
+				"  ! all values will be re-encrypted: name or namespace of a strict scoped Secret has been changed from app/foo to app/bar"},
+		},
+		"namespace": {
+			modify: func(s *corev1.Secret) { s.Namespace = "other" },
+			expected: []string{"changes:", "  metadata.namespace: app -> other",
+				"  ! all values will be re-encrypted: name or namespace of a strict scoped Secret has been changed from app/foo to other/foo"},
+		},
+		"scope": {
+			modify: func(s *corev1.Secret) { s.Annotations = map[string]string{testNamespaceWide: "true"} },
+			expected: []string{"changes:", "  scope: strict -> namespace-wide",
+				"  + metadata.annotations[" + testNamespaceWide + "]: true",
+				"  ! all values will be re-encrypted: scope has been changed from strict to namespace-wide"},
+		},
+	}
+
+	secretYAML, err := yaml.Marshal(testSummarySecret(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, test := range tests {
+		editedSecretYAML, err := yaml.Marshal(testSummarySecret(test.modify))
+		if err != nil {
+			t.Fatal(err)
+		}
+		summary, err := DescribeSecretChanges(secretYAML, editedSecretYAML)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		expected := strings.Join(test.expected, "\n") + "\n"
+		if summary != expected {
+			t.Errorf("%v: expected:\n%s\ngot:\n%s", name, expected, summary)
+		}
+		if strings.Contains(summary, "hunter") || strings.Contains(summary, "t0ken") {
+			t.Errorf("%v: expected values not to be shown, got:\n%s", name, summary)
+		}
+	}
+
+	if _, err := DescribeSecretChanges(secretYAML, []byte("bogus: true\n")); err == nil {
+		t.Errorf("expected error for malformed Secret")
+	}
+}
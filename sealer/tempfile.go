@@ -0,0 +1,166 @@
+package sealer
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// temporary files hold decrypted secrets and private keys, so we
+// - put them in a private 0700 directory, preferably on tmpfs
+// - create them with 0600
+// - overwrite their content before removal
+// - remove them even when interrupted by SIGINT/SIGTERM, for as long as any of them exists
+
+type privateTempFile struct {
+	// path to the temporary file
+	Name string
+	// private directory holding the file
+	dir string
+	// key in pendingCleanups
+	id int
+}
+
+var (
+	pendingCleanupsMu sync.Mutex
+	pendingCleanups   = map[int]*privateTempFile{}
+	nextCleanupID     int
+	handleSignalsOnce sync.Once
+	// SIGINT/SIGTERM are relayed here only while there are pending cleanups
+	cleanupSignals   = make(chan os.Signal, 1)
+	notifyingSignals bool
+)
+
+// returns the base directory for temporary files, preferring memory-backed filesystems
+func privateTempBaseDir() string {
+	candidates := []string{
+		os.Getenv("XDG_RUNTIME_DIR"),
+		"/dev/shm",
+	}
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return os.TempDir()
+}
+
+// create an empty file with 0600 inside a newly created private directory.
+// name is used as-is for the file name, so that a suffix like `.yaml` is kept.
+// caller must call Cleanup() once it's done with the file.
+func createPrivateTempFile(name string) (*privateTempFile, error) {
+	handleSignalsOnce.Do(cleanupOnSignal)
+
+	// os.MkdirTemp creates the directory with 0700
+	dir, err := os.MkdirTemp(privateTempBaseDir(), "kubectl-sealer-")
+	if err != nil {
+		return nil, fmt.Errorf("error creating temporary directory: %v", err)
+	}
+
+	t := &privateTempFile{
+		Name: filepath.Join(dir, name),
+		dir:  dir,
+	}
+	pendingCleanupsMu.Lock()
+	t.id = nextCleanupID
+	nextCleanupID++
+	pendingCleanups[t.id] = t
+	if !notifyingSignals {
+		signal.Notify(cleanupSignals, syscall.SIGINT, syscall.SIGTERM)
+		notifyingSignals = true
+	}
+	pendingCleanupsMu.Unlock()
+
+	f, err := os.OpenFile(t.Name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		t.Cleanup()
+		return nil, fmt.Errorf("error creating temporary file: %v", err)
+	}
+	f.Close()
+
+	return t, nil
+}
+
+// write content to the temporary file, truncating it first
+func (t *privateTempFile) Write(content []byte) error {
+	f, err := os.OpenFile(t.Name, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// overwrite and remove the temporary file along with its private directory.
+// it's safe to call this more than once.
+func (t *privateTempFile) Cleanup() {
+	pendingCleanupsMu.Lock()
+	delete(pendingCleanups, t.id)
+	// nothing left to clean up, so let signals kill the process as usual again
+	if len(pendingCleanups) == 0 && notifyingSignals {
+		signal.Stop(cleanupSignals)
+		notifyingSignals = false
+	}
+	pendingCleanupsMu.Unlock()
+
+	// editors may leave swap or backup files next to the buffer, so shred everything in the directory
+	entries, _ := os.ReadDir(t.dir)
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			shredFile(filepath.Join(t.dir, entry.Name()))
+		}
+	}
+	os.RemoveAll(t.dir)
+}
+
+// overwrite the file content with zeros. this is best effort;
+// copy-on-write or journaling filesystems may still keep the original blocks.
+func shredFile(name string) {
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	zeros := make([]byte, 4096)
+	for remaining := info.Size(); remaining > 0; remaining -= int64(len(zeros)) {
+		if remaining < int64(len(zeros)) {
+			zeros = zeros[:remaining]
+		}
+		if _, err := f.Write(zeros); err != nil {
+			return
+		}
+	}
+	f.Sync()
+}
+
+// make sure temporary files don't outlive the process when it's interrupted
+func cleanupOnSignal() {
+	go func() {
+		sig := <-cleanupSignals
+		pendingCleanupsMu.Lock()
+		pending := make([]*privateTempFile, 0, len(pendingCleanups))
+		for _, t := range pendingCleanups {
+			pending = append(pending, t)
+		}
+		pendingCleanupsMu.Unlock()
+		for _, t := range pending {
+			t.Cleanup()
+		}
+		// follow the shell convention of 128+signal number
+		os.Exit(128 + int(sig.(syscall.Signal)))
+	}()
+}
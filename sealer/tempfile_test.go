@@ -0,0 +1,109 @@
+package sealer
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestPrivateTempFile(t *testing.T) {
+	tempFile, err := createPrivateTempFile("secret.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tempFile.Cleanup()
+
+	if filepath.Base(tempFile.Name) != "secret.yaml" {
+		t.Errorf("expected file name to be kept, got %s", tempFile.Name)
+	}
+	if runtime.GOOS != "windows" {
+		for path, expectedPerm := range map[string]os.FileMode{tempFile.Name: 0600, tempFile.dir: 0700} {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != expectedPerm {
+				t.Errorf("%s: expected %v, got %v", path, expectedPerm, info.Mode().Perm())
+			}
+		}
+	}
+
+	content := []byte("stringData:\n  password: hunter2\n")
+	if err := tempFile.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tempFile.Write(content[:10]); err != nil {
+		t.Fatal(err)
+	}
+	written, err := os.ReadFile(tempFile.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(written, content[:10]) {
+		t.Errorf("expected Write to truncate, got %q", written)
+	}
+}
+
+func TestPrivateTempFileCleanup(t *testing.T) {
+	tempFile, err := createPrivateTempFile("secret.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("stringData:\n  password: hunter2\n")
+	if err := tempFile.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	// editors leave swap files next to the buffer
+	swapFile := filepath.Join(tempFile.dir, ".secret.yaml.swp")
+	if err := os.WriteFile(swapFile, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a hard link outside the private directory keeps the content reachable after removal,
+	// which tells whether it has been shredded
+	links := map[string]string{}
+	for _, path := range []string{tempFile.Name, swapFile} {
+		link := filepath.Join(filepath.Dir(tempFile.dir), filepath.Base(tempFile.dir)+"-"+filepath.Base(path))
+		if err := os.Link(path, link); err != nil {
+			tempFile.Cleanup()
+			t.Skipf("hard links are not supported: %v", err)
+		}
+		defer os.Remove(link)
+		links[path] = link
+	}
+
+	pendingCleanupsMu.Lock()
+	if _, pending := pendingCleanups[tempFile.id]; !pending || !notifyingSignals {
+		t.Errorf("expected pending cleanup with signals relayed")
+	}
+	pendingCleanupsMu.Unlock()
+
+	tempFile.Cleanup()
+
+	if _, err := os.Stat(tempFile.dir); !os.IsNotExist(err) {
+		t.Errorf("expected private directory to be removed, got: %v", err)
+	}
+	for path, link := range links {
+		shredded, err := os.ReadFile(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(shredded, make([]byte, len(content))) {
+			t.Errorf("%s: expected content to be overwritten with zeros, got %q", path, shredded)
+		}
+	}
+
+	pendingCleanupsMu.Lock()
+	if _, pending := pendingCleanups[tempFile.id]; pending || notifyingSignals {
+		t.Errorf("expected no pending cleanup and signals no longer relayed")
+	}
+	pendingCleanupsMu.Unlock()
+
+	// calling it again is a no-op
+	tempFile.Cleanup()
+	if _, err := os.Stat(tempFile.dir); !os.IsNotExist(err) {
+		t.Errorf("expected private directory to stay removed, got: %v", err)
+	}
+}
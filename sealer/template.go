@@ -0,0 +1,157 @@
+package sealer
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// name of the directory in ConfigDirs() holding user defined templates
+const TemplatesDirName = "templates"
+
+// built-in templates, one for each Secret type. user defined templates with the same name take precedence.
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+// names of built-in templates for each Secret type
+var templateNamesByType = map[corev1.SecretType]string{
+	corev1.SecretTypeOpaque:              "opaque",
+	corev1.SecretTypeServiceAccountToken: "service-account-token",
+	corev1.SecretTypeDockercfg:           "dockercfg",
+	corev1.SecretTypeDockerConfigJson:    "dockerconfigjson",
+	corev1.SecretTypeBasicAuth:           "basic-auth",
+	corev1.SecretTypeSSHAuth:             "ssh-auth",
+	corev1.SecretTypeTLS:                 "tls",
+	corev1.SecretTypeBootstrapToken:      "bootstrap-token",
+}
+
+// returns name of the built-in template for the Secret type
+func TemplateNameForType(secretType corev1.SecretType) (string, error) {
+	name, ok := templateNamesByType[secretType]
+	if !ok {
+		return "", fmt.Errorf("no such type exists for Secret: \"%s\"", secretType)
+	}
+	return name, nil
+}
+
+// TemplateValues is passed to templates as `.`
+type TemplateValues struct {
+	Name      string
+	Namespace string
+}
+
+// read the template with the given name, i.e. `<name>.yaml` in the templates directory of
+// ConfigDirs(), or the built-in one
+func ReadTemplate(name string) ([]byte, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid template name: %q", name)
+	}
+	filename := name + ".yaml"
+
+	if configFilename := FindConfigFile(filepath.Join(TemplatesDirName, filename)); configFilename != "" {
+		content, err := os.ReadFile(configFilename)
+		if err != nil {
+			return nil, fmt.Errorf("error reading template: %v", err)
+		}
+		return content, nil
+	}
+
+	content, err := builtinTemplates.ReadFile("templates/" + filename)
+	if err != nil {
+		return nil, fmt.Errorf("no such template: %q, available templates are %v", name, ListTemplates())
+	}
+	return content, nil
+}
+
+// returns names of all available templates, both user defined and built-in
+func ListTemplates() []string {
+	names := map[string]string{}
+	addNames := func(filenames []string) {
+		for _, filename := range filenames {
+			name := strings.TrimSuffix(filepath.Base(filename), ".yaml")
+			names[name] = name
+		}
+	}
+
+	for _, dir := range ConfigDirs() {
+		filenames, _ := filepath.Glob(filepath.Join(dir, TemplatesDirName, "*.yaml"))
+		addNames(filenames)
+	}
+	if entries, err := builtinTemplates.ReadDir("templates"); err == nil {
+		filenames := []string{}
+		for _, entry := range entries {
+			filenames = append(filenames, entry.Name())
+		}
+		addNames(filenames)
+	}
+
+	return SortedKeys(names)
+}
+
+// render the template into a Secret YAML. templates are Go text/template with these functions:
+//
+//   prompt "question" ["default"]  asks for a value; the same question is asked only once
+//   promptSecret "question"        same as prompt, without echoing the answer back
+//   quote VALUE                    quotes the value so that it's safe to put in YAML as-is
+//
+// as well as GeneratorFuncs, e.g. `{{ generate 32 "alnum" | quote }}`.
+//
+// e.g.
+//
+//   stringData:
+//     host: {{ prompt "host" | quote }}
+//     password: {{ promptSecret "password" | quote }}
+//     dsn: {{ printf "postgres://%s@%s/%s" (prompt "user") (prompt "host") .Name | quote }}
+func RenderSecretTemplate(name string, content []byte, values TemplateValues) ([]byte, error) {
+	answers := map[string]string{}
+	ask := func(question string, promptFunc func() (string, error)) (string, error) {
+		if answer, ok := answers[question]; ok {
+			return answer, nil
+		}
+		answer, err := promptFunc()
+		if err != nil {
+			return "", err
+		}
+		answers[question] = answer
+		return answer, nil
+	}
+
+	funcs := template.FuncMap{
+		"prompt": func(question string, defaultValue ...string) (string, error) {
+			if len(defaultValue) > 1 {
+				return "", fmt.Errorf("prompt takes at most one default value")
+			}
+			return ask(question, func() (string, error) {
+				return Prompt(question, strings.Join(defaultValue, ""))
+			})
+		},
+		"promptSecret": func(question string) (string, error) {
+			return ask(question, func() (string, error) {
+				return PromptSecret(question)
+			})
+		},
+		"quote": func(value string) (string, error) {
+			// JSON strings are valid YAML flow scalars
+			quoted, err := json.Marshal(value)
+			return string(quoted), err
+		},
+	}
+
+	tmpl, err := template.New(name).Funcs(GeneratorFuncs).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	var rendered bytes.Buffer
+	err = tmpl.Execute(&rendered, values)
+	if err != nil {
+		return nil, fmt.Errorf("error rendering template: %v", err)
+	}
+	return rendered.Bytes(), nil
+}
@@ -0,0 +1,104 @@
+package sealer
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestBuiltinTemplates(t *testing.T) {
+	for secretType, name := range templateNamesByType {
+		content, err := ReadTemplate(name)
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		rendered, err := RenderSecretTemplate(name, content, TemplateValues{Name: "foo", Namespace: "default"})
+		if err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		var secret corev1.Secret
+		if err := yaml.UnmarshalStrict(rendered, &secret); err != nil {
+			t.Errorf("%v: Unexpected error: %v", name, err)
+			continue
+		}
+		if secret.Type != secretType {
+			t.Errorf("%v: expected type %s, got %s", name, secretType, secret.Type)
+		}
+	}
+}
+
+func TestRenderSecretTemplate(t *testing.T) {
+	content := []byte(`type: Opaque
+stringData:
+  host: {{ prompt "host" | quote }}
+  port: {{ prompt "port" "5432" | quote }}
+  password: {{ promptSecret "password" | quote }}
+  dsn: {{ printf "postgres://%s@%s/%s" .Name (prompt "host") .Namespace | quote }}
+`)
+	// stdin is not a terminal in tests, so promptSecret reads a line as well
+	stdinReader = bufio.NewReader(strings.NewReader("db.example.com\n\np\"ss: word\n"))
+	defer func() { stdinReader = bufio.NewReader(os.Stdin) }()
+
+	rendered, err := RenderSecretTemplate("postgres", content, TemplateValues{Name: "app", Namespace: "prod"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var secret corev1.Secret
+	if err := yaml.UnmarshalStrict(rendered, &secret); err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, rendered)
+	}
+	expected := map[string]string{
+		"host":     "db.example.com",
+		"port":     "5432",
+		"password": `p"ss: word`,
+		"dsn":      "postgres://app@db.example.com/prod",
+	}
+	for key, value := range expected {
+		if secret.StringData[key] != value {
+			t.Errorf("%v: expected %q, got %q", key, value, secret.StringData[key])
+		}
+	}
+
+	if _, err := RenderSecretTemplate("broken", []byte("{{ .Nope }}"), TemplateValues{}); err == nil {
+		t.Errorf("Expected error for unknown field, got none")
+	}
+}
+
+func TestReadTemplateFromConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, repoConfigDirName, TemplatesDirName), 0755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, repoConfigDirName, TemplatesDirName, "tls.yaml"), []byte("type: kubernetes.io/tls\n"), 0644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer os.Chdir(cwd)
+
+	content, err := ReadTemplate("tls")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if string(content) != "type: kubernetes.io/tls\n" {
+		t.Errorf("expected user defined template to take precedence, got %q", content)
+	}
+	if _, err := ReadTemplate("../tls"); err == nil {
+		t.Errorf("Expected error for invalid name, got none")
+	}
+	if _, err := ReadTemplate("nonexistent"); err == nil {
+		t.Errorf("Expected error for nonexistent template, got none")
+	}
+}
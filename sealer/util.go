@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 )
 
 func GetEnv(key string, fallback string) string {
@@ -14,27 +15,49 @@ func GetEnv(key string, fallback string) string {
 	return value
 }
 
+// returns the editor command line to use. explicitly given editor takes precedence,
+// then KUBE_EDITOR (as kubectl edit does), VISUAL and EDITOR, falling back to vi.
+// the value may contain arguments, e.g. `code --wait`
+func resolveEditor(editor string) ([]string, error) {
+	if editor == "" {
+		editor = GetEnv("KUBE_EDITOR", GetEnv("VISUAL", GetEnv("EDITOR", "vi")))
+	}
+	editorArgs, err := splitShellWords(editor)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing editor command: %v", err)
+	}
+	if len(editorArgs) == 0 {
+		return nil, fmt.Errorf("error parsing editor command: empty command: %q", editor)
+	}
+	return editorArgs, nil
+}
+
 // take content, open editor, then return edited content
 // a.k.a. vipe
-func EditWithEditor(content []byte) (editedContent []byte, err error) {
+func EditWithEditor(content []byte, editor string) (editedContent []byte, err error) {
 
 	// set editor
-	editor := GetEnv("VISUAL", GetEnv("EDITOR", "vi"))
+	editorArgs, err := resolveEditor(editor)
+	if err != nil {
+		return nil, err
+	}
 
-	// create and open a temporary file for editing
-	f, err := os.CreateTemp("", "kubectl-sealer-")
+	// create a private temporary file for editing
+	// `.yaml` suffix lets editors enable syntax highlighting
+	t, err := createPrivateTempFile("secret.yaml")
 	if err != nil {
-		return nil, fmt.Errorf("error creating temporary file: %v", err)
+		return nil, err
 	}
-	// remove the temporary file at the end of the program
-	defer os.Remove(f.Name())
+	// shred and remove the temporary file at the end of the program
+	defer t.Cleanup()
 
-	// write content and close immediately so that we can run editor
-	f.Write(content)
-	f.Close()
+	err = t.Write(content)
+	if err != nil {
+		return nil, fmt.Errorf("error writing temporary file: %v", err)
+	}
 
 	// run editor
-	editorCommand := exec.Command(editor, f.Name())
+	editorCommand := exec.Command(editorArgs[0], append(editorArgs[1:], t.Name)...)
 	editorCommand.Stdin = os.Stdin
 	editorCommand.Stdout = os.Stdout
 	err = editorCommand.Run()
@@ -43,7 +66,7 @@ func EditWithEditor(content []byte) (editedContent []byte, err error) {
 	}
 
 	// read content after editing
-	editedContent, err = os.ReadFile(f.Name())
+	editedContent, err = os.ReadFile(t.Name)
 	if err != nil {
 		return nil, fmt.Errorf("error: %v", err)
 	}
@@ -51,7 +74,7 @@ func EditWithEditor(content []byte) (editedContent []byte, err error) {
 	return editedContent, nil
 }
 
-// given map A and B, returns list of keys only exists in map A
+// given map A and B, returns sorted list of keys only exists in map A
 // if there is no such key, returns empty slice
 func GetKeyDiff(a map[string]string, b map[string]string) (keys []string) {
 	keys = []string{}
@@ -61,6 +84,18 @@ func GetKeyDiff(a map[string]string, b map[string]string) (keys []string) {
 			keys = append(keys, aKey)
 		}
 	}
+	// map iteration order is random, so sort to keep the result stable
+	sort.Strings(keys)
+	return keys
+}
+
+// returns keys of the map in sorted order
+func SortedKeys(m map[string]string) (keys []string) {
+	keys = make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	return keys
 }
 
@@ -80,7 +80,9 @@ func ValidateSecret(secret *core.Secret) field.ErrorList {
 		// make sure that the content is well-formed json.
 		if err := json.Unmarshal(dockerConfigJSONBytes, &map[string]interface{}{}); err != nil {
 			allErrs = append(allErrs, field.Invalid(dataPath.Key(core.DockerConfigJsonKey), "<secret contents redacted>", err.Error()))
+			break
 		}
+		allErrs = append(allErrs, validateDockerConfigJSON(dockerConfigJSONBytes, dataPath.Key(core.DockerConfigJsonKey))...)
 	case core.SecretTypeBasicAuth:
 		_, usernameFieldExists := secret.Data[core.BasicAuthUsernameKey]
 		_, passwordFieldExists := secret.Data[core.BasicAuthPasswordKey]
@@ -96,15 +98,22 @@ func ValidateSecret(secret *core.Secret) field.ErrorList {
 			allErrs = append(allErrs, field.Required(field.NewPath("data[%s]").Key(core.SSHAuthPrivateKey), ""))
 			break
 		}
+		allErrs = append(allErrs, validateSSHPrivateKey(secret.Data[core.SSHAuthPrivateKey], dataPath.Key(core.SSHAuthPrivateKey))...)
 
 	case core.SecretTypeTLS:
-		if _, exists := secret.Data[core.TLSCertKey]; !exists {
+		_, certExists := secret.Data[core.TLSCertKey]
+		if !certExists {
 			allErrs = append(allErrs, field.Required(dataPath.Key(core.TLSCertKey), ""))
 		}
-		if _, exists := secret.Data[core.TLSPrivateKeyKey]; !exists {
+		_, keyExists := secret.Data[core.TLSPrivateKeyKey]
+		if !keyExists {
 			allErrs = append(allErrs, field.Required(dataPath.Key(core.TLSPrivateKeyKey), ""))
 		}
-	// TODO: Verify that the key matches the cert.
+		if certExists && keyExists {
+			allErrs = append(allErrs, validateTLSKeyPair(secret.Data[core.TLSCertKey], secret.Data[core.TLSPrivateKeyKey], dataPath)...)
+		}
+	case core.SecretTypeBootstrapToken:
+		allErrs = append(allErrs, validateBootstrapToken(secret, dataPath)...)
 	default:
 		// no-op
 	}
@@ -0,0 +1,106 @@
+package sealer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// constants of bootstrap token Secrets. these are defined in k8s.io/cluster-bootstrap/token/api
+// https://github.com/kubernetes/cluster-bootstrap/blob/v0.22.2/token/api/types.go
+const (
+	bootstrapTokenSecretPrefix   = "bootstrap-token-"
+	bootstrapTokenIDKey          = "token-id"
+	bootstrapTokenSecretKey      = "token-secret"
+	bootstrapTokenExpirationKey  = "expiration"
+	bootstrapTokenUsagePrefix    = "usage-bootstrap-"
+	bootstrapTokenExtraGroupsKey = "auth-extra-groups"
+)
+
+var (
+	bootstrapTokenIDRegexp     = regexp.MustCompile(`^[a-z0-9]{6}$`)
+	bootstrapTokenSecretRegexp = regexp.MustCompile(`^[a-z0-9]{16}$`)
+	bootstrapGroupRegexp       = regexp.MustCompile(`^system:bootstrappers:[a-z0-9:-]{0,255}[a-z0-9]$`)
+
+	knownBootstrapTokenUsages = []string{"signing", "authentication"}
+)
+
+// verify a bootstrap.kubernetes.io/token Secret the same way kube-apiserver and
+// kube-controller-manager interpret it; malformed tokens are silently ignored by them.
+func validateBootstrapToken(secret *core.Secret, dataPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	tokenID, exists := secret.Data[bootstrapTokenIDKey]
+	if !exists {
+		allErrs = append(allErrs, field.Required(dataPath.Key(bootstrapTokenIDKey), ""))
+	} else if !bootstrapTokenIDRegexp.Match(tokenID) {
+		allErrs = append(allErrs, field.Invalid(dataPath.Key(bootstrapTokenIDKey), string(tokenID), fmt.Sprintf("must match %s", bootstrapTokenIDRegexp)))
+	} else {
+		// the token is only looked up by this name in kube-system
+		expectedName := bootstrapTokenSecretPrefix + string(tokenID)
+		if secret.Name != expectedName {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "name"), secret.Name, fmt.Sprintf("must be %q to match %s", expectedName, bootstrapTokenIDKey)))
+		}
+	}
+	if secret.Namespace != metav1.NamespaceSystem {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "namespace"), secret.Namespace, fmt.Sprintf("bootstrap tokens must be in %q", metav1.NamespaceSystem)))
+	}
+
+	tokenSecret, exists := secret.Data[bootstrapTokenSecretKey]
+	if !exists {
+		allErrs = append(allErrs, field.Required(dataPath.Key(bootstrapTokenSecretKey), ""))
+	} else if !bootstrapTokenSecretRegexp.Match(tokenSecret) {
+		allErrs = append(allErrs, field.Invalid(dataPath.Key(bootstrapTokenSecretKey), "<secret contents redacted>", fmt.Sprintf("must match %s", bootstrapTokenSecretRegexp)))
+	}
+
+	if expiration, exists := secret.Data[bootstrapTokenExpirationKey]; exists {
+		if _, err := time.Parse(time.RFC3339, string(expiration)); err != nil {
+			allErrs = append(allErrs, field.Invalid(dataPath.Key(bootstrapTokenExpirationKey), string(expiration), fmt.Sprintf("must be in RFC3339 format: %v", err)))
+		}
+	}
+
+	for _, key := range SortedKeys(stringMap(secret.Data)) {
+		if !strings.HasPrefix(key, bootstrapTokenUsagePrefix) {
+			continue
+		}
+		usage := strings.TrimPrefix(key, bootstrapTokenUsagePrefix)
+		if !containsString(knownBootstrapTokenUsages, usage) {
+			allErrs = append(allErrs, field.NotSupported(dataPath.Key(key), usage, knownBootstrapTokenUsages))
+		}
+		if value := string(secret.Data[key]); value != "true" && value != "false" {
+			allErrs = append(allErrs, field.NotSupported(dataPath.Key(key), value, []string{"true", "false"}))
+		}
+	}
+
+	if groups, exists := secret.Data[bootstrapTokenExtraGroupsKey]; exists {
+		for _, group := range strings.Split(string(groups), ",") {
+			if !bootstrapGroupRegexp.MatchString(group) {
+				allErrs = append(allErrs, field.Invalid(dataPath.Key(bootstrapTokenExtraGroupsKey), group, fmt.Sprintf("each group must match %s", bootstrapGroupRegexp)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func stringMap(data map[string][]byte) map[string]string {
+	m := make(map[string]string, len(data))
+	for k, v := range data {
+		m[k] = string(v)
+	}
+	return m
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package sealer
+
+import (
+	"strings"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateBootstrapToken(t *testing.T) {
+	validBootstrapToken := func() core.Secret {
+		return core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "bootstrap-token-abcdef", Namespace: "kube-system"},
+			Type:       core.SecretTypeBootstrapToken,
+			Data: map[string][]byte{
+				"token-id":                       []byte("abcdef"),
+				"token-secret":                   []byte("0123456789abcdef"),
+				"expiration":                     []byte("2030-01-01T00:00:00Z"),
+				"usage-bootstrap-authentication": []byte("true"),
+				"usage-bootstrap-signing":        []byte("true"),
+				"auth-extra-groups":              []byte("system:bootstrappers:kubeadm:default-node-token"),
+				"description":                    []byte("anything goes here"),
+			},
+		}
+	}
+
+	var (
+		minimal            = validBootstrapToken()
+		wrongName          = validBootstrapToken()
+		wrongNamespace     = validBootstrapToken()
+		missingTokenID     = validBootstrapToken()
+		invalidTokenID     = validBootstrapToken()
+		missingTokenSecret = validBootstrapToken()
+		invalidTokenSecret = validBootstrapToken()
+		invalidExpiration  = validBootstrapToken()
+		unknownUsage       = validBootstrapToken()
+		invalidUsageValue  = validBootstrapToken()
+		invalidExtraGroup  = validBootstrapToken()
+	)
+
+	minimal.Data = map[string][]byte{
+		"token-id":     []byte("abcdef"),
+		"token-secret": []byte("0123456789abcdef"),
+	}
+	wrongName.Name = "bootstrap-token-123456"
+	wrongNamespace.Namespace = "default"
+	delete(missingTokenID.Data, "token-id")
+	invalidTokenID.Data["token-id"] = []byte("ABCDEF")
+	delete(missingTokenSecret.Data, "token-secret")
+	invalidTokenSecret.Data["token-secret"] = []byte("tooshort")
+	invalidExpiration.Data["expiration"] = []byte("2030-01-01")
+	unknownUsage.Data["usage-bootstrap-everything"] = []byte("true")
+	invalidUsageValue.Data["usage-bootstrap-signing"] = []byte("yes")
+	invalidExtraGroup.Data["auth-extra-groups"] = []byte("system:bootstrappers:ok,system:masters")
+
+	tests := map[string]struct {
+		secret      core.Secret
+		valid       bool
+		errorDetail string
+	}{
+		"valid":                {validBootstrapToken(), true, ""},
+		"minimal":              {minimal, true, ""},
+		"wrong name":           {wrongName, false, "metadata.name"},
+		"wrong namespace":      {wrongNamespace, false, "metadata.namespace"},
+		"missing token-id":     {missingTokenID, false, "data[token-id]: Required value"},
+		"invalid token-id":     {invalidTokenID, false, "data[token-id]"},
+		"missing token-secret": {missingTokenSecret, false, "data[token-secret]: Required value"},
+		"invalid token-secret": {invalidTokenSecret, false, "data[token-secret]"},
+		"invalid expiration":   {invalidExpiration, false, "data[expiration]"},
+		"unknown usage":        {unknownUsage, false, "data[usage-bootstrap-everything]"},
+		"invalid usage value":  {invalidUsageValue, false, "data[usage-bootstrap-signing]"},
+		"invalid extra group":  {invalidExtraGroup, false, "system:masters"},
+	}
+
+	for name, tc := range tests {
+		errs := ValidateSecret(&tc.secret)
+		if tc.valid && len(errs) > 0 {
+			t.Errorf("%v: Unexpected error: %v", name, errs)
+		}
+		if !tc.valid {
+			if len(errs) == 0 {
+				t.Errorf("%v: Unexpected non-error", name)
+			} else if !strings.Contains(errs.ToAggregate().Error(), tc.errorDetail) {
+				t.Errorf("%v: Expected error with detail %q, got %v", name, tc.errorDetail, errs)
+			}
+		}
+		// token secret must never be part of error messages
+		if len(errs) > 0 && strings.Contains(errs.ToAggregate().Error(), "tooshort") {
+			t.Errorf("%v: Error contains secret value: %v", name, errs)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package sealer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// structure of .dockerconfigjson as read by kubelet
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth,omitempty"`
+}
+
+// verify the structure of .dockerconfigjson beyond being a well-formed json:
+// - `auths` exists and is not empty
+// - registry keys look like a host, optionally with scheme, port and path
+// - each entry has either `auth` or `username` and `password`
+// - `auth` is base64 encoded `username:password`, and agrees with explicit username/password if both given
+func validateDockerConfigJSON(dockerConfigJSONBytes []byte, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	var config dockerConfigJSON
+	if err := json.Unmarshal(dockerConfigJSONBytes, &config); err != nil {
+		return append(allErrs, field.Invalid(fldPath, "<secret contents redacted>", fmt.Sprintf("unexpected structure: %v", err)))
+	}
+	if len(config.Auths) == 0 {
+		return append(allErrs, field.Invalid(fldPath, "<secret contents redacted>", "auths: must have at least one registry entry"))
+	}
+
+	// sort so that errors are reported in a stable order
+	registries := make([]string, 0, len(config.Auths))
+	for registry := range config.Auths {
+		registries = append(registries, registry)
+	}
+	sort.Strings(registries)
+
+	for _, registry := range registries {
+		entry := config.Auths[registry]
+		for _, msg := range validateRegistryKey(registry) {
+			allErrs = append(allErrs, field.Invalid(fldPath, "<secret contents redacted>", fmt.Sprintf("auths[%s]: %s", registry, msg)))
+		}
+		for _, msg := range validateDockerConfigEntry(entry) {
+			allErrs = append(allErrs, field.Invalid(fldPath, "<secret contents redacted>", fmt.Sprintf("auths[%s]: %s", registry, msg)))
+		}
+	}
+
+	return allErrs
+}
+
+func validateDockerConfigEntry(entry dockerConfigEntry) []string {
+	if entry.Auth == "" {
+		if entry.Username == "" || entry.Password == "" {
+			return []string{"must have either auth, or both username and password"}
+		}
+		return nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+	if err != nil {
+		return []string{fmt.Sprintf("auth is not valid base64: %v", err)}
+	}
+	// password may contain colons, but username may not
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return []string{"auth must be base64 encoded \"username:password\""}
+	}
+	msgs := []string{}
+	if entry.Username != "" && entry.Username != parts[0] {
+		msgs = append(msgs, "username does not match the one encoded in auth")
+	}
+	if entry.Password != "" && entry.Password != parts[1] {
+		msgs = append(msgs, "password does not match the one encoded in auth")
+	}
+	return msgs
+}
+
+// registry keys are matched against image names by kubelet, e.g. `registry.example.com:5000`,
+// `https://index.docker.io/v1/`, or `registry.example.com/path` are all fine
+func validateRegistryKey(registry string) []string {
+	host := registry
+	for _, scheme := range []string{"https://", "http://"} {
+		if strings.HasPrefix(host, scheme) {
+			host = strings.TrimPrefix(host, scheme)
+			break
+		}
+	}
+	if strings.Contains(host, "://") {
+		return []string{"only http:// or https:// scheme is allowed"}
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	if host == "" {
+		return []string{"registry host must not be empty"}
+	}
+
+	// kubelet supports wildcards like `*.example.com`
+	hostname := host
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		hostname = h
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return []string{fmt.Sprintf("invalid port: %q", port)}
+		}
+	}
+	if net.ParseIP(hostname) != nil {
+		return nil
+	}
+	// host names are case insensitive
+	hostname = strings.ToLower(hostname)
+	if strings.HasPrefix(hostname, "*.") {
+		return validation.IsWildcardDNS1123Subdomain(hostname)
+	}
+	return validation.IsDNS1123Subdomain(hostname)
+}
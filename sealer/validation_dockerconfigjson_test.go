@@ -0,0 +1,74 @@
+package sealer
+
+import (
+	"strings"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateDockerConfigJSON(t *testing.T) {
+	dockerConfigJSONSecret := func(config string) core.Secret {
+		return core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Type:       core.SecretTypeDockerConfigJson,
+			Data: map[string][]byte{
+				core.DockerConfigJsonKey: []byte(config),
+			},
+		}
+	}
+
+	// "user:pass" and "user:pa:ss"
+	const auth = "dXNlcjpwYXNz"
+	const authWithColon = "dXNlcjpwYTpzcw=="
+
+	tests := map[string]struct {
+		config      string
+		valid       bool
+		errorDetail string
+	}{
+		"auth only":                    {`{"auths":{"registry.example.com":{"auth":"` + auth + `"}}}`, true, ""},
+		"username and password only":   {`{"auths":{"registry.example.com":{"username":"user","password":"pass"}}}`, true, ""},
+		"consistent auth and fields":   {`{"auths":{"registry.example.com":{"username":"user","password":"pass","auth":"` + auth + `"}}}`, true, ""},
+		"password with colon":          {`{"auths":{"registry.example.com":{"username":"user","password":"pa:ss","auth":"` + authWithColon + `"}}}`, true, ""},
+		"docker hub url":               {`{"auths":{"https://index.docker.io/v1/":{"auth":"` + auth + `"}}}`, true, ""},
+		"host with port and path":      {`{"auths":{"registry.example.com:5000/team":{"auth":"` + auth + `"}}}`, true, ""},
+		"ip address":                   {`{"auths":{"10.0.0.1:5000":{"auth":"` + auth + `"}}}`, true, ""},
+		"wildcard host":                {`{"auths":{"*.example.com":{"auth":"` + auth + `"}}}`, true, ""},
+		"uppercase host":               {`{"auths":{"Registry.Example.com":{"auth":"` + auth + `"}}}`, true, ""},
+		"missing auths":                {`{"registry.example.com":{"auth":"` + auth + `"}}`, false, "auths: must have at least one registry entry"},
+		"empty auths":                  {`{"auths":{}}`, false, "auths: must have at least one registry entry"},
+		"auths is not an object":       {`{"auths":[]}`, false, "unexpected structure"},
+		"no credentials":               {`{"auths":{"registry.example.com":{"email":"foo@example.com"}}}`, false, "must have either auth, or both username and password"},
+		"username without password":    {`{"auths":{"registry.example.com":{"username":"user"}}}`, false, "must have either auth, or both username and password"},
+		"auth is not base64":           {`{"auths":{"registry.example.com":{"auth":"user:pass"}}}`, false, "auth is not valid base64"},
+		"auth without colon":           {`{"auths":{"registry.example.com":{"auth":"dXNlcnBhc3M="}}}`, false, `auth must be base64 encoded "username:password"`},
+		"username does not match":      {`{"auths":{"registry.example.com":{"username":"other","auth":"` + auth + `"}}}`, false, "username does not match"},
+		"password does not match":      {`{"auths":{"registry.example.com":{"password":"other","auth":"` + auth + `"}}}`, false, "password does not match"},
+		"registry with typo":           {`{"auths":{"registry..example.com":{"auth":"` + auth + `"}}}`, false, "auths[registry..example.com]"},
+		"registry with space":          {`{"auths":{"registry example.com":{"auth":"` + auth + `"}}}`, false, "auths[registry example.com]"},
+		"registry with invalid port":   {`{"auths":{"registry.example.com:http":{"auth":"` + auth + `"}}}`, false, "invalid port"},
+		"registry with unknown scheme": {`{"auths":{"oci://registry.example.com":{"auth":"` + auth + `"}}}`, false, "only http:// or https:// scheme is allowed"},
+		"empty registry":               {`{"auths":{"https://":{"auth":"` + auth + `"}}}`, false, "registry host must not be empty"},
+	}
+
+	for name, tc := range tests {
+		secret := dockerConfigJSONSecret(tc.config)
+		errs := ValidateSecret(&secret)
+		if tc.valid && len(errs) > 0 {
+			t.Errorf("%v: Unexpected error: %v", name, errs)
+		}
+		if !tc.valid {
+			if len(errs) == 0 {
+				t.Errorf("%v: Unexpected non-error", name)
+			} else if !strings.Contains(errs.ToAggregate().Error(), tc.errorDetail) {
+				t.Errorf("%v: Expected error with detail %q, got %v", name, tc.errorDetail, errs)
+			}
+		}
+		// secret values must never be part of error messages
+		if len(errs) > 0 && strings.Contains(errs.ToAggregate().Error(), "pass\"") {
+			t.Errorf("%v: Error contains secret value: %v", name, errs)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package sealer
+
+import (
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// verify ssh-privatekey is a private key which ssh clients can load, either in OpenSSH or PEM format.
+// passphrase protected keys are accepted, since we can't check them any further without the passphrase.
+func validateSSHPrivateKey(privateKey []byte, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	_, err := ssh.ParseRawPrivateKey(privateKey)
+	if err != nil {
+		if _, ok := err.(*ssh.PassphraseMissingError); ok {
+			return allErrs
+		}
+		allErrs = append(allErrs, field.Invalid(fldPath, "<secret contents redacted>", err.Error()))
+	}
+	return allErrs
+}
@@ -0,0 +1,49 @@
+package sealer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateSSHPrivateKey(t *testing.T) {
+	readKey := func(name string) []byte {
+		key, err := os.ReadFile(filepath.Join("testdata", "ssh", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	tests := map[string]struct {
+		privateKey []byte
+		valid      bool
+	}{
+		"openssh format":           {readKey("id_ed25519"), true},
+		"openssh format encrypted": {readKey("id_ed25519_encrypted"), true},
+		"pem format":               {readKey("id_rsa_pem"), true},
+		"placeholder":              {[]byte("changeme"), false},
+		"public key":               {[]byte("ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIH2p4ZzD3o0o1Gk0b3Z2z6ZzQ9S3I5tqkN8f0f2m0f1m test"), false},
+		"truncated key":            {readKey("id_ed25519")[:200], false},
+	}
+
+	for name, tc := range tests {
+		secret := core.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
+			Type:       core.SecretTypeSSHAuth,
+			Data: map[string][]byte{
+				core.SSHAuthPrivateKey: tc.privateKey,
+			},
+		}
+		errs := ValidateSecret(&secret)
+		if tc.valid && len(errs) > 0 {
+			t.Errorf("%v: Unexpected error: %v", name, errs)
+		}
+		if !tc.valid && len(errs) == 0 {
+			t.Errorf("%v: Unexpected non-error", name)
+		}
+	}
+}
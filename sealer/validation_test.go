@@ -7,8 +7,11 @@ Original copyright: Copyright 2014 The Kubernetes Authors
 package sealer
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -211,12 +214,17 @@ func TestValidateBasicAuthSecret(t *testing.T) {
 
 // https://github.com/kubernetes/kubernetes/blob/7fbb384e15e2fb8370513eab9443c5107df1b257/pkg/apis/core/validation/validation_test.go#L16395
 func TestValidateSSHAuthSecret(t *testing.T) {
+	// unlike upstream, the private key is parsed, so it has to be a real one
+	privateKey, err := os.ReadFile(filepath.Join("testdata", "ssh", "id_ed25519"))
+	if err != nil {
+		t.Fatal(err)
+	}
 	validSSHAuthSecret := func() core.Secret {
 		return core.Secret{
 			ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "bar"},
 			Type:       core.SecretTypeSSHAuth,
 			Data: map[string][]byte{
-				core.SSHAuthPrivateKey: []byte("foo-bar-baz"),
+				core.SSHAuthPrivateKey: privateKey,
 			},
 		}
 	}
@@ -246,13 +254,15 @@ func TestValidateSSHAuthSecret(t *testing.T) {
 
 // https://github.com/kubernetes/kubernetes/blob/7fbb384e15e2fb8370513eab9443c5107df1b257/pkg/apis/core/validation/validation_test.go#L16731
 func TestValidateTLSSecret(t *testing.T) {
+	// unlike upstream, the key pair is verified, so it has to be a real one
+	keyPair := newTestCertificate(t, "tls-cert", []string{"example.com"}, time.Now().Add(time.Hour), nil)
 	successCases := map[string]core.Secret{
-		"empty certificate chain": {
+		"valid key pair": {
 			ObjectMeta: metav1.ObjectMeta{Name: "tls-cert", Namespace: "namespace"},
 			Type:       core.SecretTypeTLS,
 			Data: map[string][]byte{
-				core.TLSCertKey:       []byte("public key"),
-				core.TLSPrivateKeyKey: []byte("private key"),
+				core.TLSCertKey:       keyPair.certPEM,
+				core.TLSPrivateKeyKey: keyPair.keyPEM,
 			},
 		},
 	}
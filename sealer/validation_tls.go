@@ -0,0 +1,103 @@
+package sealer
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// certificates expiring within this period are warned about
+const certificateExpiryWarningPeriod = 30 * 24 * time.Hour
+
+// verify that tls.crt and tls.key of a kubernetes.io/tls Secret are parseable,
+// that the key matches the leaf certificate, and that the chain is ordered leaf first.
+// both keys are expected to exist; their presence is checked by ValidateSecret.
+func validateTLSKeyPair(certPEM []byte, keyPEM []byte, dataPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	certPath := dataPath.Key(core.TLSCertKey)
+	keyPath := dataPath.Key(core.TLSPrivateKeyKey)
+
+	certs, err := parseCertificateChain(certPEM)
+	if err != nil {
+		return append(allErrs, field.Invalid(certPath, "<secret contents redacted>", err.Error()))
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return append(allErrs, field.Invalid(keyPath, "<secret contents redacted>", "no PEM encoded private key found"))
+	}
+
+	// tls.X509KeyPair parses the key and checks it against the first certificate in the chain
+	if _, err := tls.X509KeyPair(certPEM, keyPEM); err != nil {
+		allErrs = append(allErrs, field.Invalid(keyPath, "<secret contents redacted>", err.Error()))
+	}
+
+	// each certificate must be issued by the next one
+	for i := 0; i < len(certs)-1; i++ {
+		if err := certs[i].CheckSignatureFrom(certs[i+1]); err != nil {
+			allErrs = append(allErrs, field.Invalid(certPath, "<secret contents redacted>",
+				fmt.Sprintf("certificate #%d (%s) is not signed by certificate #%d (%s); the chain must start with the leaf certificate followed by its issuers: %v",
+					i, certs[i].Subject, i+1, certs[i+1].Subject, err)))
+		}
+	}
+
+	return allErrs
+}
+
+// returns warnings on tls.crt which don't make the Secret invalid, but are likely mistakes
+func tlsCertificateWarnings(certPEM []byte, now time.Time) []string {
+	certs, err := parseCertificateChain(certPEM)
+	if err != nil {
+		// reported by validateTLSKeyPair
+		return nil
+	}
+
+	warnings := []string{}
+	for i, cert := range certs {
+		switch {
+		case now.After(cert.NotAfter):
+			warnings = append(warnings, fmt.Sprintf("data[%s]: certificate #%d (%s) expired at %s", core.TLSCertKey, i, cert.Subject, cert.NotAfter.Format(time.RFC3339)))
+		case now.Add(certificateExpiryWarningPeriod).After(cert.NotAfter):
+			warnings = append(warnings, fmt.Sprintf("data[%s]: certificate #%d (%s) expires soon at %s", core.TLSCertKey, i, cert.Subject, cert.NotAfter.Format(time.RFC3339)))
+		case now.Before(cert.NotBefore):
+			warnings = append(warnings, fmt.Sprintf("data[%s]: certificate #%d (%s) is not valid until %s", core.TLSCertKey, i, cert.Subject, cert.NotBefore.Format(time.RFC3339)))
+		}
+	}
+
+	// clients ignore the common name, so a serving certificate without SANs is unusable
+	leaf := certs[0]
+	if len(leaf.DNSNames) == 0 && len(leaf.IPAddresses) == 0 && len(leaf.URIs) == 0 && len(leaf.EmailAddresses) == 0 {
+		warnings = append(warnings, fmt.Sprintf("data[%s]: certificate (%s) has no subject alternative names", core.TLSCertKey, leaf.Subject))
+	}
+
+	return warnings
+}
+
+func parseCertificateChain(certPEM []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	rest := certPEM
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("unexpected PEM block of type %q, only CERTIFICATE is allowed", block.Type)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate #%d: %v", len(certs), err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return certs, nil
+}
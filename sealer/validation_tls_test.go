@@ -0,0 +1,147 @@
+package sealer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// issue a certificate for testing. if issuer is nil, the certificate is self-signed CA.
+func newTestCertificate(t *testing.T, cn string, dnsNames []string, notAfter time.Time, issuer *testCertificate) *testCertificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              dnsNames,
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  issuer == nil,
+	}
+	parent, signer := template, key
+	if issuer != nil {
+		parent, signer = issuer.cert, issuer.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func tlsSecret(certPEM []byte, keyPEM []byte) core.Secret {
+	return core.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls-cert", Namespace: "namespace"},
+		Type:       core.SecretTypeTLS,
+		Data: map[string][]byte{
+			core.TLSCertKey:       certPEM,
+			core.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+}
+
+func TestValidateTLSKeyPair(t *testing.T) {
+	notAfter := time.Now().Add(365 * 24 * time.Hour)
+	ca := newTestCertificate(t, "ca", nil, notAfter, nil)
+	leaf := newTestCertificate(t, "leaf", []string{"example.com"}, notAfter, ca)
+	other := newTestCertificate(t, "other", []string{"example.com"}, notAfter, ca)
+
+	tests := map[string]struct {
+		secret      core.Secret
+		valid       bool
+		errorDetail string
+	}{
+		"self-signed":                {tlsSecret(ca.certPEM, ca.keyPEM), true, ""},
+		"leaf only":                  {tlsSecret(leaf.certPEM, leaf.keyPEM), true, ""},
+		"leaf followed by issuer":    {tlsSecret(bytes.Join([][]byte{leaf.certPEM, ca.certPEM}, nil), leaf.keyPEM), true, ""},
+		"key does not match":         {tlsSecret(leaf.certPEM, other.keyPEM), false, "data[tls.key]"},
+		"issuer followed by leaf":    {tlsSecret(bytes.Join([][]byte{ca.certPEM, leaf.certPEM}, nil), leaf.keyPEM), false, "data[tls.key]"},
+		"issuer before leaf's key":   {tlsSecret(bytes.Join([][]byte{ca.certPEM, leaf.certPEM}, nil), ca.keyPEM), false, "is not signed by certificate #1"},
+		"unrelated certs in chain":   {tlsSecret(bytes.Join([][]byte{leaf.certPEM, other.certPEM}, nil), leaf.keyPEM), false, "is not signed by certificate #1"},
+		"cert is not PEM":            {tlsSecret([]byte("changeme"), leaf.keyPEM), false, "no PEM encoded certificate found"},
+		"key is not PEM":             {tlsSecret(leaf.certPEM, []byte("changeme")), false, "no PEM encoded private key found"},
+		"key in place of cert":       {tlsSecret(leaf.keyPEM, leaf.keyPEM), false, "only CERTIFICATE is allowed"},
+		"broken certificate content": {tlsSecret(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("bad")}), leaf.keyPEM), false, "error parsing certificate #0"},
+	}
+
+	for name, tc := range tests {
+		errs := ValidateSecret(&tc.secret)
+		if tc.valid && len(errs) > 0 {
+			t.Errorf("%v: Unexpected error: %v", name, errs)
+		}
+		if !tc.valid {
+			if len(errs) == 0 {
+				t.Errorf("%v: Unexpected non-error", name)
+			} else if !strings.Contains(errs.ToAggregate().Error(), tc.errorDetail) {
+				t.Errorf("%v: Expected error with detail %q, got %v", name, tc.errorDetail, errs)
+			}
+		}
+	}
+}
+
+func TestTLSCertificateWarnings(t *testing.T) {
+	now := time.Now()
+	ca := newTestCertificate(t, "ca", nil, now.Add(365*24*time.Hour), nil)
+
+	tests := map[string]struct {
+		certPEM []byte
+		warning string
+	}{
+		"valid":         {newTestCertificate(t, "leaf", []string{"example.com"}, now.Add(365*24*time.Hour), ca).certPEM, ""},
+		"expired":       {newTestCertificate(t, "leaf", []string{"example.com"}, now.Add(-time.Minute), ca).certPEM, "expired"},
+		"expires soon":  {newTestCertificate(t, "leaf", []string{"example.com"}, now.Add(24*time.Hour), ca).certPEM, "expires soon"},
+		"no SANs":       {newTestCertificate(t, "leaf", nil, now.Add(365*24*time.Hour), ca).certPEM, "no subject alternative names"},
+		"not parseable": {[]byte("changeme"), ""},
+		"expired in chain": {bytes.Join([][]byte{
+			newTestCertificate(t, "leaf", []string{"example.com"}, now.Add(365*24*time.Hour), ca).certPEM,
+			newTestCertificate(t, "old-ca", nil, now.Add(-time.Minute), nil).certPEM,
+		}, nil), "certificate #1 (CN=old-ca) expired"},
+	}
+
+	for name, tc := range tests {
+		warnings := tlsCertificateWarnings(tc.certPEM, now)
+		if tc.warning == "" && len(warnings) > 0 {
+			t.Errorf("%v: Unexpected warnings: %v", name, warnings)
+		}
+		if tc.warning != "" && !strings.Contains(strings.Join(warnings, "\n"), tc.warning) {
+			t.Errorf("%v: Expected warning %q, got %v", name, tc.warning, warnings)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package sealer
+
+import (
+	"time"
+
+	core "k8s.io/api/core/v1"
+)
+
+// returns warnings on the Secret which don't make it invalid, but are likely mistakes.
+// unlike ValidateSecret, these don't block sealing.
+func GetWarningsForSecret(secret *core.Secret) []string {
+	warnings := []string{}
+
+	switch secret.Type {
+	case core.SecretTypeTLS:
+		if certPEM, exists := secret.Data[core.TLSCertKey]; exists {
+			warnings = append(warnings, tlsCertificateWarnings(certPEM, time.Now())...)
+		}
+	}
+
+	return warnings
+}